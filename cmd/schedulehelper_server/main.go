@@ -3,8 +3,24 @@
 
 package main
 
-import "github.com/bytesyntax/schedule-helper/internal/core"
+import (
+	"flag"
+	"log"
+
+	"github.com/bytesyntax/schedule-helper/internal/core"
+)
 
 func main() {
-	core.RunHeadless()
+	configPath := flag.String("config", "", "options file (toml, yaml or json)")
+	flag.Parse()
+
+	opts := core.DefaultProcessOptions()
+	if *configPath != "" {
+		var err error
+		opts, err = core.LoadProcessOptionsFile(*configPath)
+		if err != nil {
+			log.Fatal(err)
+		}
+	}
+	core.RunHeadlessWithOptions(opts)
 }
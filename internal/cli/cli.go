@@ -0,0 +1,581 @@
+package cli
+
+import (
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"sort"
+	"strings"
+	"syscall"
+	"text/tabwriter"
+	"time"
+
+	"github.com/bytesyntax/schedule-helper/internal/core"
+)
+
+// Exit codes returned by Run
+const (
+	ExitOK      = 0
+	ExitFailure = 1
+	ExitUsage   = 2
+)
+
+type command struct {
+	summary string
+	run     func(env *env, args []string) int
+}
+
+// env holds the standard streams so commands can be tested
+type env struct {
+	stdin  io.Reader
+	stdout io.Writer
+	stderr io.Writer
+}
+
+var commands = map[string]command{
+	"render":   {"render schedules to a folder or zip", runRender},
+	"validate": {"check input files and report problems", runValidate},
+	"inspect":  {"print parsed shifts as a table or JSON", runInspect},
+	"watch":    {"generate schedules when exports land in a folder", runWatch},
+	"generate": {"propose shifts from demand and availability", runGenerate},
+	"diff":     {"report shift changes between two exports", runDiff},
+}
+
+/*
+================================================================================
+Run the command line interface, returns the process exit code
+================================================================================
+*/
+func Run(args []string, stdin io.Reader, stdout io.Writer, stderr io.Writer) int {
+	e := &env{stdin: stdin, stdout: stdout, stderr: stderr}
+	if len(args) == 0 || args[0] == "-h" || args[0] == "help" {
+		e.usage()
+		return ExitUsage
+	}
+	cmd, ok := commands[args[0]]
+	if !ok {
+		fmt.Fprintf(stderr, "unknown command %q\n", args[0])
+		e.usage()
+		return ExitUsage
+	}
+	return cmd.run(e, args[1:])
+}
+
+func (e *env) usage() {
+	fmt.Fprintln(e.stderr, "Usage: schedulehelper_cli <command> [flags]")
+	fmt.Fprintln(e.stderr, "")
+	fmt.Fprintln(e.stderr, "Commands:")
+	names := make([]string, 0, len(commands))
+	for name := range commands {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		fmt.Fprintf(e.stderr, "  %-10s %s\n", name, commands[name].summary)
+	}
+}
+
+func (e *env) fail(err error) int {
+	fmt.Fprintln(e.stderr, "Error:", err)
+	return ExitFailure
+}
+
+// inputFlags are shared by all commands reading an export
+type inputFlags struct {
+	input    string
+	settings string
+	footer   string
+	config   string
+	sources  map[string]*string // optional data sources by key
+}
+
+func (f *inputFlags) register(fs *flag.FlagSet) {
+	fs.StringVar(&f.input, "input", "", "shift export (*.xlsx), - for stdin")
+	fs.StringVar(&f.settings, "settings", "", "settings file with phone and role (*.xlsx)")
+	fs.StringVar(&f.footer, "footer", "", "footer file (*.xlsx)")
+	fs.StringVar(&f.config, "config", "", "options file (toml, yaml or json)")
+	f.sources = map[string]*string{}
+	for _, src := range core.OptionalSources {
+		f.sources[src.Key] = fs.String(src.Key, "", src.Help)
+	}
+}
+
+// missingInput reports a missing -input, a base schedule can stand in for it
+func (f *inputFlags) missingInput(e *env, command string) bool {
+	if f.input == "" && *f.sources["base"] == "" {
+		fmt.Fprintf(e.stderr, "%s: -input or -base is required\n", command)
+		return true
+	}
+	return false
+}
+
+func (f *inputFlags) options() (core.ProcessOptions, error) {
+	if f.config == "" {
+		return core.DefaultProcessOptions(), nil
+	}
+	return core.LoadProcessOptionsFile(f.config)
+}
+
+// open returns a reader for path, stdin for "-" and nil for ""
+func (e *env) open(path string) (io.Reader, func(), error) {
+	switch path {
+	case "":
+		return nil, func() {}, nil
+	case "-":
+		return e.stdin, func() {}, nil
+	}
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, func() {}, err
+	}
+	return f, func() { f.Close() }, nil
+}
+
+func newFlagSet(e *env, name string) *flag.FlagSet {
+	fs := flag.NewFlagSet(name, flag.ContinueOnError)
+	fs.SetOutput(e.stderr)
+	return fs
+}
+
+/*
+================================================================================
+render: generate schedules into a folder or a zip file
+================================================================================
+*/
+func runRender(e *env, args []string) int {
+	var in inputFlags
+	fs := newFlagSet(e, "render")
+	in.register(fs)
+	outDir := fs.String("out", ".", "output folder")
+	zipPath := fs.String("zip", "", "write a zip archive instead, - for stdout")
+	formats := fs.String("formats", "", "comma separated output formats, overrides the config (xlsx, pdf, html, ics, json, csv, payroll)")
+	if err := fs.Parse(args); err != nil {
+		return ExitUsage
+	}
+	if in.missingInput(e, "render") {
+		return ExitUsage
+	}
+
+	opts, err := in.options()
+	if err != nil {
+		return e.fail(err)
+	}
+	if *formats != "" {
+		opts.Formats = strings.Split(*formats, ",")
+		if err := opts.Validate(); err != nil {
+			return e.fail(err)
+		}
+	}
+	files, err := e.process(in, opts)
+	if err != nil {
+		return e.fail(err)
+	}
+
+	if *zipPath != "" {
+		if err := e.writeZip(*zipPath, files); err != nil {
+			return e.fail(err)
+		}
+		return ExitOK
+	}
+	if err := writeFiles(*outDir, files); err != nil {
+		return e.fail(err)
+	}
+	for _, name := range sortedNames(files) {
+		fmt.Fprintln(e.stderr, "Wrote", filepath.Join(*outDir, name))
+	}
+	return ExitOK
+}
+
+func (e *env) process(in inputFlags, opts core.ProcessOptions) (map[string][]byte, error) {
+	input, closeInput, err := e.open(in.input)
+	if err != nil {
+		return nil, err
+	}
+	defer closeInput()
+	settings, closeSettings, err := e.open(in.settings)
+	if err != nil {
+		return nil, err
+	}
+	defer closeSettings()
+	footer, closeFooter, err := e.open(in.footer)
+	if err != nil {
+		return nil, err
+	}
+	defer closeFooter()
+	closeSources, err := e.openSources(in, &opts)
+	if err != nil {
+		return nil, err
+	}
+	defer closeSources()
+
+	return core.ProcessFilesWithOptions(input, settings, footer, opts)
+}
+
+// openSources sets the optional data sources given on the command line
+func (e *env) openSources(in inputFlags, opts *core.ProcessOptions) (func(), error) {
+	closers := []func(){}
+	closeAll := func() {
+		for _, c := range closers {
+			c()
+		}
+	}
+	for _, src := range core.OptionalSources {
+		r, closeSource, err := e.open(*in.sources[src.Key])
+		if err != nil {
+			closeAll()
+			return nil, err
+		}
+		closers = append(closers, closeSource)
+		if r != nil {
+			src.Set(opts, r)
+		}
+	}
+	return closeAll, nil
+}
+
+func (e *env) writeZip(path string, files map[string][]byte) error {
+	if path == "-" {
+		return core.WriteZip(e.stdout, files)
+	}
+	f, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	if err := core.WriteZip(f, files); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
+
+func writeFiles(dir string, files map[string][]byte) error {
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return err
+	}
+	for name, content := range files {
+		if err := os.WriteFile(filepath.Join(dir, name), content, 0644); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func sortedNames(files map[string][]byte) []string {
+	names := make([]string, 0, len(files))
+	for name := range files {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+/*
+================================================================================
+validate: report problems in the input without rendering
+================================================================================
+*/
+func runValidate(e *env, args []string) int {
+	var in inputFlags
+	fs := newFlagSet(e, "validate")
+	in.register(fs)
+	asJSON := fs.Bool("json", false, "print the report as JSON")
+	if err := fs.Parse(args); err != nil {
+		return ExitUsage
+	}
+	if in.missingInput(e, "validate") {
+		return ExitUsage
+	}
+
+	opts, err := in.options()
+	if err != nil {
+		return e.fail(err)
+	}
+	input, closeInput, err := e.open(in.input)
+	if err != nil {
+		return e.fail(err)
+	}
+	defer closeInput()
+	settings, closeSettings, err := e.open(in.settings)
+	if err != nil {
+		return e.fail(err)
+	}
+	defer closeSettings()
+	closeSources, err := e.openSources(in, &opts)
+	if err != nil {
+		return e.fail(err)
+	}
+	defer closeSources()
+
+	report := core.ValidateInput(input, settings, opts)
+	if *asJSON {
+		enc := json.NewEncoder(e.stdout)
+		enc.SetIndent("", "  ")
+		enc.Encode(report)
+	} else {
+		fmt.Fprintf(e.stdout, "Shifts: %d, employees: %d, weeks: %v\n", report.Shifts, report.Employees, report.Weeks)
+		for _, w := range report.Warnings {
+			fmt.Fprintln(e.stdout, "WARNING:", w)
+		}
+		for _, err := range report.Errors {
+			fmt.Fprintln(e.stdout, "ERROR:", err)
+		}
+	}
+	if !report.OK() {
+		return ExitFailure
+	}
+	return ExitOK
+}
+
+/*
+================================================================================
+inspect: print the parsed shifts
+================================================================================
+*/
+func runInspect(e *env, args []string) int {
+	var in inputFlags
+	fs := newFlagSet(e, "inspect")
+	in.register(fs)
+	format := fs.String("format", "table", "output format: table or json")
+	if err := fs.Parse(args); err != nil {
+		return ExitUsage
+	}
+	if in.missingInput(e, "inspect") {
+		return ExitUsage
+	}
+	if *format != "table" && *format != "json" {
+		fmt.Fprintf(e.stderr, "inspect: unknown format %q\n", *format)
+		return ExitUsage
+	}
+
+	shifts, err := e.readShifts(in)
+	if err != nil {
+		return e.fail(err)
+	}
+
+	if *format == "json" {
+		enc := json.NewEncoder(e.stdout)
+		enc.SetIndent("", "  ")
+		if err := enc.Encode(shifts); err != nil {
+			return e.fail(err)
+		}
+		return ExitOK
+	}
+	tw := tabwriter.NewWriter(e.stdout, 0, 4, 2, ' ', 0)
+	fmt.Fprintln(tw, "WEEK\tDATE\tTIME\tHOURS\tLUNCH\tID\tNAME\tDEPARTMENT\tROLE\tPHONE")
+	for _, s := range shifts {
+		fmt.Fprintf(tw, "%d\t%s\t%s\t%.2f\t%v\t%d\t%s\t%s\t%s\t%s\n",
+			s.WeekNumber, s.Date, s.Time, s.ShiftLength, s.HasLunch, s.EmployeeId, s.Name(), s.Department, s.Role, s.Phone)
+	}
+	tw.Flush()
+	return ExitOK
+}
+
+func (e *env) readShifts(in inputFlags) ([]core.Shift, error) {
+	opts, err := in.options()
+	if err != nil {
+		return nil, err
+	}
+	input, closeInput, err := e.open(in.input)
+	if err != nil {
+		return nil, err
+	}
+	defer closeInput()
+	settings, closeSettings, err := e.open(in.settings)
+	if err != nil {
+		return nil, err
+	}
+	defer closeSettings()
+	closeSources, err := e.openSources(in, &opts)
+	if err != nil {
+		return nil, err
+	}
+	defer closeSources()
+
+	return core.ReadShifts(input, settings, opts)
+}
+
+/*
+================================================================================
+diff: report the shift changes between two exports of the same period
+================================================================================
+*/
+func runDiff(e *env, args []string) int {
+	fs := newFlagSet(e, "diff")
+	oldPath := fs.String("old", "", "previous export (*.xlsx)")
+	newPath := fs.String("new", "", "new export (*.xlsx), - for stdin")
+	settingsPath := fs.String("settings", "", "settings file with phone and role (*.xlsx)")
+	config := fs.String("config", "", "options file (toml, yaml or json)")
+	format := fs.String("format", "table", "output format: table or json")
+	out := fs.String("out", "", "also write the change report (*.xlsx)")
+	if err := fs.Parse(args); err != nil {
+		return ExitUsage
+	}
+	if *oldPath == "" || *newPath == "" {
+		fmt.Fprintln(e.stderr, "diff: -old and -new are required")
+		return ExitUsage
+	}
+	if *format != "table" && *format != "json" {
+		fmt.Fprintf(e.stderr, "diff: unknown format %q\n", *format)
+		return ExitUsage
+	}
+
+	opts, err := (&inputFlags{config: *config}).options()
+	if err != nil {
+		return e.fail(err)
+	}
+	before, closeBefore, err := e.open(*oldPath)
+	if err != nil {
+		return e.fail(err)
+	}
+	defer closeBefore()
+	after, closeAfter, err := e.open(*newPath)
+	if err != nil {
+		return e.fail(err)
+	}
+	defer closeAfter()
+	settings, closeSettings, err := e.open(*settingsPath)
+	if err != nil {
+		return e.fail(err)
+	}
+	defer closeSettings()
+
+	changes, err := core.DiffFiles(before, after, settings, opts)
+	if err != nil {
+		return e.fail(err)
+	}
+	if *out != "" {
+		buf, err := core.WriteDiffReport(changes, opts)
+		if err != nil {
+			return e.fail(err)
+		}
+		if err := os.WriteFile(*out, buf, 0644); err != nil {
+			return e.fail(err)
+		}
+		fmt.Fprintln(e.stderr, "Wrote", *out)
+	}
+
+	if *format == "json" {
+		enc := json.NewEncoder(e.stdout)
+		enc.SetIndent("", "  ")
+		if err := enc.Encode(map[string]any{"changes": changes, "notify": core.Notify(changes)}); err != nil {
+			return e.fail(err)
+		}
+		return ExitOK
+	}
+	tw := tabwriter.NewWriter(e.stdout, 0, 4, 2, ' ', 0)
+	fmt.Fprintln(tw, "CHANGE\tDATE\tID\tNAME\tBEFORE\tAFTER")
+	for _, c := range changes {
+		before, after := c.Before, c.After
+		if c.Kind == core.ChangeRole {
+			before, after = c.OldRole, c.NewRole
+		}
+		fmt.Fprintf(tw, "%s\t%s\t%d\t%s\t%s\t%s\n", c.Kind, c.Date, c.EmployeeId, c.Name, before, after)
+	}
+	tw.Flush()
+	for _, employee := range core.Notify(changes) {
+		fmt.Fprintf(e.stdout, "NOTIFY: %s (%d), changes: %d\n", employee.Name, employee.EmployeeId, employee.Changes)
+	}
+	return ExitOK
+}
+
+/*
+================================================================================
+generate: propose a schedule as an export the other commands read
+================================================================================
+*/
+func runGenerate(e *env, args []string) int {
+	fs := newFlagSet(e, "generate")
+	demandPath := fs.String("demand", "", "staffing demand per date and slot (*.xlsx or *.csv)")
+	availabilityPath := fs.String("availability", "", "employee availability, preferences and contract hours (*.xlsx or *.csv)")
+	config := fs.String("config", "", "options file (toml, yaml or json)")
+	seed := fs.Int64("seed", 0, "random seed for ties, overrides the config")
+	out := fs.String("out", "generated.xlsx", "generated export (*.xlsx), - for stdout")
+	if err := fs.Parse(args); err != nil {
+		return ExitUsage
+	}
+	if *demandPath == "" || *availabilityPath == "" {
+		fmt.Fprintln(e.stderr, "generate: -demand and -availability are required")
+		return ExitUsage
+	}
+
+	opts, err := (&inputFlags{config: *config}).options()
+	if err != nil {
+		return e.fail(err)
+	}
+	fs.Visit(func(f *flag.Flag) {
+		if f.Name == "seed" {
+			opts.Generator.Seed = *seed
+		}
+	})
+	demand, closeDemand, err := e.open(*demandPath)
+	if err != nil {
+		return e.fail(err)
+	}
+	defer closeDemand()
+	availability, closeAvailability, err := e.open(*availabilityPath)
+	if err != nil {
+		return e.fail(err)
+	}
+	defer closeAvailability()
+
+	buf, report, err := core.GenerateSchedule(demand, availability, opts)
+	if err != nil {
+		return e.fail(err)
+	}
+	if *out == "-" {
+		e.stdout.Write(buf)
+	} else {
+		if err := os.WriteFile(*out, buf, 0644); err != nil {
+			return e.fail(err)
+		}
+		fmt.Fprintln(e.stderr, "Wrote", *out)
+	}
+	fmt.Fprintf(e.stderr, "Shifts: %d\n", report.Shifts)
+	for _, slot := range report.Unfilled {
+		fmt.Fprintln(e.stderr, "UNFILLED:", slot)
+	}
+	return ExitOK
+}
+
+/*
+================================================================================
+watch: long-running mode generating schedules for dropped exports
+================================================================================
+*/
+func runWatch(e *env, args []string) int {
+	var cfg core.WatchConfig
+	fs := newFlagSet(e, "watch")
+	fs.StringVar(&cfg.InputDir, "dir", "", "folder to watch for exports")
+	fs.StringVar(&cfg.OutputDir, "out", "", "folder for generated schedules")
+	fs.StringVar(&cfg.ArchiveDir, "archive", "", "folder for processed exports (default <dir>/processed)")
+	fs.StringVar(&cfg.FailedDir, "failed", "", "folder for failed exports (default <dir>/failed)")
+	fs.StringVar(&cfg.SettingsName, "settings-name", "settings.xlsx", "settings file name inside the watched folder")
+	fs.StringVar(&cfg.FooterName, "footer-name", "footer.xlsx", "footer file name inside the watched folder")
+	fs.DurationVar(&cfg.Debounce, "debounce", 2*time.Second, "wait this long after the last write")
+	config := fs.String("config", "", "options file (toml, yaml or json)")
+	if err := fs.Parse(args); err != nil {
+		return ExitUsage
+	}
+	if cfg.InputDir == "" || cfg.OutputDir == "" {
+		fmt.Fprintln(e.stderr, "watch: -dir and -out are required")
+		return ExitUsage
+	}
+
+	opts, err := (&inputFlags{config: *config}).options()
+	if err != nil {
+		return e.fail(err)
+	}
+	cfg.Options = opts
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+	if err := core.RunWatch(ctx, cfg); err != nil {
+		return e.fail(err)
+	}
+	return ExitOK
+}
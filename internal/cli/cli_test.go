@@ -0,0 +1,240 @@
+package cli
+
+import (
+	"archive/zip"
+	"bytes"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/bytesyntax/schedule-helper/internal/core"
+	"github.com/xuri/excelize/v2"
+)
+
+// writeInputFile creates an export workbook in a temp folder and returns its path
+func writeInputFile(t *testing.T, shifts [][]string) string {
+	t.Helper()
+	f := excelize.NewFile()
+	defer f.Close()
+	f.SetSheetName("Sheet1", "Worksheet")
+	f.SetSheetRow("Worksheet", "A1", &[]interface{}{"Export"})
+	f.SetSheetRow("Worksheet", "A2", &[]interface{}{"Id", "Efternamn", "Förnamn", "Typ", "Datum", "Tid", "Avdelning"})
+	for i, shift := range shifts {
+		row := make([]interface{}, len(shift))
+		for j, v := range shift {
+			row[j] = v
+		}
+		cell, _ := excelize.CoordinatesToCellName(1, i+3)
+		f.SetSheetRow("Worksheet", cell, &row)
+	}
+	path := filepath.Join(t.TempDir(), "export.xlsx")
+	if err := f.SaveAs(path); err != nil {
+		t.Fatalf("saving input file: %v", err)
+	}
+	return path
+}
+
+var testShifts = [][]string{
+	{"1", "Svensson", "Anna", "Pass", "2025-03-17", "09:00 - 17:00", "Kassa"},
+	{"2", "Berg", "Erik", "Pass", "2025-03-18", "12:00 - 16:00", "Kassa"},
+}
+
+func run(t *testing.T, stdin []byte, args ...string) (int, string, string) {
+	t.Helper()
+	var stdout, stderr bytes.Buffer
+	code := Run(args, bytes.NewReader(stdin), &stdout, &stderr)
+	return code, stdout.String(), stderr.String()
+}
+
+func TestRunUsage(t *testing.T) {
+	if code, _, _ := run(t, nil); code != ExitUsage {
+		t.Fatalf("expected usage exit code, got %d", code)
+	}
+	if code, _, stderr := run(t, nil, "bogus"); code != ExitUsage || !strings.Contains(stderr, "unknown command") {
+		t.Fatalf("expected unknown command, got %d %q", code, stderr)
+	}
+	if code, _, _ := run(t, nil, "render"); code != ExitUsage {
+		t.Fatalf("expected usage exit code for missing input, got %d", code)
+	}
+}
+
+func TestRenderToFolder(t *testing.T) {
+	input := writeInputFile(t, testShifts)
+	out := t.TempDir()
+	code, _, stderr := run(t, nil, "render", "-input", input, "-out", out)
+	if code != ExitOK {
+		t.Fatalf("render failed with %d: %s", code, stderr)
+	}
+	if _, err := os.Stat(filepath.Join(out, "Vecka 12.xlsx")); err != nil {
+		t.Fatalf("expected output file: %v", err)
+	}
+}
+
+func TestRenderFormats(t *testing.T) {
+	input := writeInputFile(t, testShifts)
+	out := t.TempDir()
+	code, _, stderr := run(t, nil, "render", "-input", input, "-out", out, "-formats", "pdf")
+	if code != ExitOK {
+		t.Fatalf("render failed with %d: %s", code, stderr)
+	}
+	for _, name := range []string{"Vecka 12.pdf", "Vecka 12 - Monday.pdf", "Vecka 12 - Tuesday.pdf"} {
+		if _, err := os.Stat(filepath.Join(out, name)); err != nil {
+			t.Fatalf("expected output file: %v", err)
+		}
+	}
+	if _, err := os.Stat(filepath.Join(out, "Vecka 12.xlsx")); err == nil {
+		t.Fatalf("xlsx should not be written when only pdf is requested")
+	}
+	if code, _, _ := run(t, nil, "render", "-input", input, "-out", out, "-formats", "doc"); code != ExitFailure {
+		t.Fatalf("expected failure for unknown format, got %d", code)
+	}
+}
+
+func TestRenderStdinToZipStdout(t *testing.T) {
+	content, err := os.ReadFile(writeInputFile(t, testShifts))
+	if err != nil {
+		t.Fatalf("reading input: %v", err)
+	}
+	code, stdout, stderr := run(t, content, "render", "-input", "-", "-zip", "-")
+	if code != ExitOK {
+		t.Fatalf("render failed with %d: %s", code, stderr)
+	}
+	zr, err := zip.NewReader(strings.NewReader(stdout), int64(len(stdout)))
+	if err != nil {
+		t.Fatalf("opening zip: %v", err)
+	}
+	if len(zr.File) != 1 || zr.File[0].Name != "Vecka 12.xlsx" {
+		t.Fatalf("unexpected zip content: %v", zr.File)
+	}
+}
+
+// Anything the core prints to the process stdout would end up in the archive
+func TestRenderZipToProcessStdout(t *testing.T) {
+	input := writeInputFile(t, testShifts)
+	out, err := os.Create(filepath.Join(t.TempDir(), "stdout.zip"))
+	if err != nil {
+		t.Fatalf("creating stdout file: %v", err)
+	}
+	defer out.Close()
+	stdout := os.Stdout
+	os.Stdout = out
+	var stderr bytes.Buffer
+	code := Run([]string{"render", "-input", input, "-zip", "-"}, nil, os.Stdout, &stderr)
+	os.Stdout = stdout
+	if code != ExitOK {
+		t.Fatalf("render failed with %d: %s", code, stderr.String())
+	}
+
+	zr, err := zip.OpenReader(out.Name())
+	if err != nil {
+		t.Fatalf("opening zip written to stdout: %v", err)
+	}
+	defer zr.Close()
+	if len(zr.File) != 1 || zr.File[0].Name != "Vecka 12.xlsx" {
+		t.Fatalf("unexpected zip content: %v", zr.File)
+	}
+	// Leading bytes would still let the reader find the central directory
+	content, _ := os.ReadFile(out.Name())
+	if !bytes.HasPrefix(content, []byte("PK\x03\x04")) {
+		t.Fatalf("expected the archive to start the output, got %q", content[:min(len(content), 40)])
+	}
+}
+
+func TestValidate(t *testing.T) {
+	input := writeInputFile(t, append(testShifts, []string{"3", "Ek", "Olle", "Pass", "2025-03-18", "16:00 - 12:00", "Kassa"}))
+	code, stdout, _ := run(t, nil, "validate", "-input", input)
+	if code != ExitFailure {
+		t.Fatalf("expected failure exit code, got %d", code)
+	}
+	if !strings.Contains(stdout, "ends before it starts") {
+		t.Fatalf("expected reversed shift error, got %q", stdout)
+	}
+
+	input = writeInputFile(t, testShifts)
+	if code, stdout, _ := run(t, nil, "validate", "-input", input); code != ExitOK {
+		t.Fatalf("expected valid input, got %d: %s", code, stdout)
+	}
+}
+
+func TestInspectJSON(t *testing.T) {
+	input := writeInputFile(t, testShifts)
+	code, stdout, stderr := run(t, nil, "inspect", "-input", input, "-format", "json")
+	if code != ExitOK {
+		t.Fatalf("inspect failed with %d: %s", code, stderr)
+	}
+	var shifts []core.Shift
+	if err := json.Unmarshal([]byte(stdout), &shifts); err != nil {
+		t.Fatalf("decoding output: %v", err)
+	}
+	if len(shifts) != 2 || shifts[0].Name() != "Anna Svensson" || !shifts[0].HasLunch {
+		t.Fatalf("unexpected shifts: %+v", shifts)
+	}
+}
+
+func TestGenerateThenInspect(t *testing.T) {
+	dir := t.TempDir()
+	demand := filepath.Join(dir, "demand.csv")
+	availability := filepath.Join(dir, "availability.csv")
+	os.WriteFile(demand, []byte("date,slot,required\n2025-03-17,10:00,1\n2025-03-17,11:00,1\n2025-03-17,12:00,1\n"), 0644)
+	os.WriteFile(availability, []byte("employeeId,lastName,firstName,department,day\n1,Svensson,Anna,Kassa,monday\n"), 0644)
+	out := filepath.Join(dir, "generated.xlsx")
+	code, _, stderr := run(t, nil, "generate", "-demand", demand, "-availability", availability, "-seed", "7", "-out", out)
+	if code != ExitOK || !strings.Contains(stderr, "Shifts: 1") {
+		t.Fatalf("generate failed with %d: %s", code, stderr)
+	}
+
+	code, stdout, stderr := run(t, nil, "inspect", "-input", out, "-format", "json")
+	if code != ExitOK {
+		t.Fatalf("inspect failed with %d: %s", code, stderr)
+	}
+	var shifts []core.Shift
+	if err := json.Unmarshal([]byte(stdout), &shifts); err != nil {
+		t.Fatalf("decoding output: %v", err)
+	}
+	if len(shifts) != 1 || shifts[0].Time != "10:00 - 13:00" {
+		t.Fatalf("unexpected shifts: %+v", shifts)
+	}
+}
+
+func TestRenderBaseSchedule(t *testing.T) {
+	dir := t.TempDir()
+	base := filepath.Join(dir, "base.csv")
+	config := filepath.Join(dir, "options.toml")
+	os.WriteFile(base, []byte("employeeId,lastName,firstName,department,cycleWeek,weekday,time\n1,Svensson,Anna,Kassa,1,monday,09:00 - 17:00\n"), 0644)
+	os.WriteFile(config, []byte("[rotation]\nstart = \"2025-03-17\"\nfrom = \"2025-03-17\"\nto = \"2025-03-23\"\n"), 0644)
+	out := filepath.Join(dir, "out")
+	code, _, stderr := run(t, nil, "render", "-base", base, "-config", config, "-out", out)
+	if code != ExitOK {
+		t.Fatalf("render failed with %d: %s", code, stderr)
+	}
+	if _, err := os.Stat(filepath.Join(out, "Vecka 12.xlsx")); err != nil {
+		t.Fatalf("expected the expanded week: %v", err)
+	}
+}
+
+func TestDiff(t *testing.T) {
+	old := writeInputFile(t, testShifts)
+	updated := writeInputFile(t, [][]string{
+		{"1", "Svensson", "Anna", "Pass", "2025-03-17", "10:00 - 18:00", "Kassa"},
+		{"3", "Lind", "Sara", "Pass", "2025-03-19", "08:00 - 12:00", "Kassa"},
+	})
+	report := filepath.Join(t.TempDir(), "changes.xlsx")
+	code, stdout, stderr := run(t, nil, "diff", "-old", old, "-new", updated, "-out", report)
+	if code != ExitOK {
+		t.Fatalf("diff failed with %d: %s", code, stderr)
+	}
+	for _, want := range []string{"moved", "removed", "added", "NOTIFY: Anna Svensson (1), changes: 1", "NOTIFY: Sara Lind (3)"} {
+		if !strings.Contains(stdout, want) {
+			t.Fatalf("expected %q in output:\n%s", want, stdout)
+		}
+	}
+	if _, err := os.Stat(report); err != nil {
+		t.Fatalf("expected the change report: %v", err)
+	}
+
+	if code, _, _ := run(t, nil, "diff", "-old", old); code != ExitUsage {
+		t.Fatalf("expected usage error without -new, got %d", code)
+	}
+}
@@ -0,0 +1,228 @@
+package core
+
+import (
+	"cmp"
+	"errors"
+	"fmt"
+	"io"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/go-gota/gota/dataframe"
+	"github.com/xuri/excelize/v2"
+)
+
+// Absence is vacation, sick leave or other time off
+type Absence struct {
+	EmployeeId int
+	Name       string // optional, the shifts' name is used when empty
+	From       string // YYYY-MM-DD
+	To         string // YYYY-MM-DD, inclusive
+	Hours      string // empty for whole days, a window "08:00-12:00" or a number of hours taken from the end of the shift
+	Type       string // e.g. Semester, Sjuk
+
+	start, end int     // window in minutes of the day, -1 without one
+	hours      float64 // number of hours without a window
+}
+
+/*
+================================================================================
+Read the absences file: employeeId, from, to, optional hours and type
+================================================================================
+*/
+func readAbsences(r io.Reader) ([]Absence, error) {
+	rows, err := readTable(r)
+	if err != nil {
+		return nil, errors.New("Error reading absences: " + err.Error())
+	}
+	absences := []Absence{}
+	for i, record := range tableRecords(rows) {
+		fail := func(err error) error {
+			return fmt.Errorf("Error reading absences row %d: %v", i+2, err)
+		}
+		id, err := strconv.Atoi(record["employeeid"])
+		if err != nil {
+			return nil, fail(fmt.Errorf("invalid employeeId %q", record["employeeid"]))
+		}
+		from, err := normalizeDate(record["from"])
+		if err != nil {
+			return nil, fail(err)
+		}
+		to := from
+		if record["to"] != "" {
+			if to, err = normalizeDate(record["to"]); err != nil {
+				return nil, fail(err)
+			}
+		}
+		if to < from {
+			return nil, fail(fmt.Errorf("to %s is before from %s", to, from))
+		}
+		absence := Absence{EmployeeId: id, Name: record["name"], From: from, To: to,
+			Hours: record["hours"], Type: record["type"], start: -1, end: -1}
+		if absence.Hours != "" {
+			if start, end, ok := strings.Cut(absence.Hours, "-"); ok {
+				if absence.start, err = minuteOfDay(strings.TrimSpace(start)); err != nil {
+					return nil, fail(err)
+				}
+				if absence.end, err = minuteOfDay(strings.TrimSpace(end)); err != nil {
+					return nil, fail(err)
+				}
+			} else if absence.hours, err = strconv.ParseFloat(strings.ReplaceAll(absence.Hours, ",", "."), 64); err != nil {
+				return nil, fail(fmt.Errorf("hours must be a window like 08:00-12:00 or a number, got %q", absence.Hours))
+			}
+		}
+		absences = append(absences, absence)
+	}
+	return absences, nil
+}
+
+// absencesOn returns the absences covering a date
+func absencesOn(absences []Absence, date string) []Absence {
+	day := []Absence{}
+	for _, a := range absences {
+		if a.From <= date && date <= a.To {
+			day = append(day, a)
+		}
+	}
+	return day
+}
+
+// overlap tells how much of a shift, in minutes of the day, the absence takes
+func (a Absence) overlap(start int, end int) (full bool, partial bool) {
+	switch {
+	case a.start >= 0:
+		if a.start <= start && end <= a.end {
+			return true, false
+		}
+		return false, a.start < end && start < a.end
+	case a.hours > 0:
+		if a.hours*60 >= float64(end-start) {
+			return true, false
+		}
+		return false, true
+	}
+	return true, false
+}
+
+// excludes reports whether the employee is away at a minute of the shift
+func (a Absence) excludes(minute int, shiftStart int, shiftEnd int) bool {
+	full, _ := a.overlap(shiftStart, shiftEnd)
+	if full {
+		return true
+	}
+	if a.start < 0 {
+		// Hours without a window are placed at the end of the shift, as if leaving early
+		return float64(shiftEnd-minute) <= a.hours*60
+	}
+	return a.start <= minute && minute < a.end
+}
+
+// label is shown in the list under the grid, e.g. "Semester" or "Sjuk 08:00-12:00"
+func (a Absence) label() string {
+	parts := []string{}
+	if a.Type != "" {
+		parts = append(parts, a.Type)
+	}
+	switch {
+	case a.start >= 0:
+		parts = append(parts, fmt.Sprintf("%02d:%02d-%02d:%02d", a.start/60, a.start%60, a.end/60, a.end%60))
+	case a.hours > 0:
+		parts = append(parts, strconv.FormatFloat(a.hours, 'f', -1, 64)+" h")
+	}
+	return strings.Join(parts, " ")
+}
+
+// shiftSpan returns the start and end of row i of a day in minutes, past midnight ends above 24h
+func shiftSpan(dateDf dataframe.DataFrame, i int) (int, int, error) {
+	start, err := time.Parse(time.TimeOnly, dateDf.Col("startTime").Elem(i).String())
+	if err != nil {
+		return 0, 0, errors.New("Error parsing startTime: " + err.Error())
+	}
+	end, err := time.Parse(time.TimeOnly, dateDf.Col("endTime").Elem(i).String())
+	if err != nil {
+		return 0, 0, errors.New("Error parsing endTime: " + err.Error())
+	}
+	s, e := start.Hour()*60+start.Minute(), end.Hour()*60+end.Minute()
+	if e <= s {
+		e += 24 * 60
+	}
+	return s, e, nil
+}
+
+// markAbsences strikes or flags the shifts of a day and returns everyone away
+func markAbsences(dayData *DaySchedule, dateDf dataframe.DataFrame, absences []Absence, names map[int]string) ([]dayAbsence, error) {
+	for i := range dayData.shifts {
+		shift := &dayData.shifts[i]
+		start, end, err := shiftSpan(dateDf, i)
+		if err != nil {
+			return nil, err
+		}
+		for j, a := range absences {
+			if a.EmployeeId != shift.employeeId {
+				continue
+			}
+			full, partial := a.overlap(start, end)
+			if full || partial {
+				shift.absence = &absences[j]
+				shift.absentAll = full
+			}
+			if full {
+				break
+			}
+		}
+	}
+	absent := []dayAbsence{}
+	for _, a := range absences {
+		name := cmp.Or(a.Name, names[a.EmployeeId], "Id "+strconv.Itoa(a.EmployeeId))
+		absent = append(absent, dayAbsence{name: name, label: a.label()})
+	}
+	slices.SortStableFunc(absent, func(a, b dayAbsence) int { return strings.Compare(a.name, b.name) })
+	return absent, nil
+}
+
+// employeeNames maps employee ids to "First Last" for absences without a name
+func employeeNames(df dataframe.DataFrame) map[int]string {
+	names := map[int]string{}
+	ids, first, last := df.Col("employeeId").Records(), df.Col("firstName").Records(), df.Col("lastName").Records()
+	for i, id := range ids {
+		if n, err := strconv.Atoi(id); err == nil {
+			names[n] = first[i] + " " + last[i]
+		}
+	}
+	return names
+}
+
+// dayAbsence is one line of the absences list
+type dayAbsence struct {
+	name  string
+	label string
+}
+
+// writeAbsences renders the absences list, a title row followed by one
+// merged row per absent employee
+func writeAbsences(f *excelize.File, sheet string, absent []dayAbsence, theme ThemeOptions, styles sheetStyles, col int, width int, row int) error {
+	for i := -1; i < len(absent); i++ {
+		start, err := excelize.CoordinatesToCellName(col, row+i+1)
+		if err != nil {
+			return fmt.Errorf("error calculating absences cell: %v", err)
+		}
+		end, _ := excelize.CoordinatesToCellName(col+width-1, row+i+1)
+		if err := f.MergeCell(sheet, start, end); err != nil {
+			return fmt.Errorf("error merging absences cells: %v", err)
+		}
+		if i < 0 {
+			f.SetCellValue(sheet, start, theme.AbsencesTitle)
+			f.SetCellStyle(sheet, start, end, styles.header)
+			continue
+		}
+		text := absent[i].name
+		if absent[i].label != "" {
+			text += ": " + absent[i].label
+		}
+		f.SetCellValue(sheet, start, text)
+		f.SetCellStyle(sheet, start, end, styles.note)
+	}
+	return nil
+}
@@ -0,0 +1,114 @@
+package core
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/xuri/excelize/v2"
+)
+
+const absencesCSV = `employeeId;from;to;hours;type
+1;2025-03-17;2025-03-18;;Semester
+2;2025-03-18;;10:00-12:00;Sjuk
+9;2025-03-19;;4;VAB
+`
+
+func TestReadAbsences(t *testing.T) {
+	absences, err := readAbsences(strings.NewReader(absencesCSV))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(absences) != 3 || absences[1].To != "2025-03-18" || absences[1].start != 10*60 || absences[2].hours != 4 {
+		t.Fatalf("unexpected absences %+v", absences)
+	}
+	if day := absencesOn(absences, "2025-03-18"); len(day) != 2 {
+		t.Fatalf("expected two absences on Tuesday, got %v", day)
+	}
+
+	// A window covering the shift takes all of it, hours shorter than the shift only flag it
+	if full, _ := absences[1].overlap(10*60, 12*60); !full {
+		t.Fatalf("expected the window to cover 10-12")
+	}
+	if full, partial := absences[1].overlap(10*60, 14*60); full || !partial {
+		t.Fatalf("expected the window to take part of 10-14")
+	}
+	if full, partial := absences[2].overlap(9*60, 17*60); full || !partial {
+		t.Fatalf("expected 4 hours to take part of an 8 hour shift")
+	}
+	// Hours without a window come off the end of the shift
+	if absences[2].excludes(12*60, 9*60, 17*60) || !absences[2].excludes(13*60, 9*60, 17*60) {
+		t.Fatalf("expected 4 hours to take 13-17 of a 9-17 shift")
+	}
+	if absences[1].label() != "Sjuk 10:00-12:00" || absences[2].label() != "VAB 4 h" {
+		t.Fatalf("unexpected labels %q %q", absences[1].label(), absences[2].label())
+	}
+
+	if _, err := readAbsences(strings.NewReader("employeeId,from,to\n1,2025-03-18,2025-03-17\n")); err == nil {
+		t.Fatalf("expected to before from to fail")
+	}
+	if _, err := readAbsences(strings.NewReader("employeeId,from,hours\n1,2025-03-18,halv\n")); err == nil {
+		t.Fatalf("expected invalid hours to fail")
+	}
+}
+
+func TestProcessFilesAbsences(t *testing.T) {
+	opts := DefaultProcessOptions()
+	opts.Absences = strings.NewReader(absencesCSV)
+	opts.Demand = strings.NewReader("date,slot,required\n2025-03-18,11:00,1\n2025-03-18,13:00,1\n")
+	result, err := ProcessFilesWithOptions(buildInputFile(t, employeeShifts), nil, nil, opts)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	f, err := excelize.OpenReader(bytes.NewReader(result["Vecka 12.xlsx"]))
+	if err != nil {
+		t.Fatalf("opening result: %v", err)
+	}
+	defer f.Close()
+
+	// Anna's shift is struck, the list starts after the trailing header and a blank row
+	expect := map[string]string{
+		"Monday!B3":    "Anna Svensson",
+		"Monday!A6":    "Frånvarande",
+		"Monday!A7":    "Anna Svensson: Semester",
+		"Wednesday!A7": "Id 9: VAB 4 h",
+	}
+	for ref, want := range expect {
+		sheet, cell, _ := strings.Cut(ref, "!")
+		if got, _ := f.GetCellValue(sheet, cell); got != want {
+			t.Fatalf("expected %q in %s, got %q", want, ref, got)
+		}
+	}
+	styleID, _ := f.GetCellStyle("Monday", "B3")
+	style, err := f.GetStyle(styleID)
+	if err != nil || style.Font == nil || !style.Font.Strike {
+		t.Fatalf("expected a struck name cell, got %+v (%v)", style, err)
+	}
+
+	// Erik is sick until noon and only counts from 12
+	rows, _ := f.GetRows("Gaps")
+	if len(rows) != 3 || rows[2][2] != "11:00-12:00" || rows[2][4] != "0" {
+		t.Fatalf("expected only the 11:00 slot short, got %v", rows)
+	}
+}
+
+func TestProcessFilesAbsenceHours(t *testing.T) {
+	opts := DefaultProcessOptions()
+	// Anna works 09-17 on Monday and is away the last 3 hours
+	opts.Absences = strings.NewReader("employeeId;from;hours;type\n1;2025-03-17;3;VAB\n")
+	opts.Demand = strings.NewReader("date,slot,required\n2025-03-17,13:00,1\n2025-03-17,15:00,1\n")
+	result, err := ProcessFilesWithOptions(buildInputFile(t, employeeShifts), nil, nil, opts)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	f, err := excelize.OpenReader(bytes.NewReader(result["Vecka 12.xlsx"]))
+	if err != nil {
+		t.Fatalf("opening result: %v", err)
+	}
+	defer f.Close()
+
+	rows, _ := f.GetRows("Gaps")
+	if len(rows) != 3 || rows[2][2] != "15:00-16:00" || rows[2][4] != "0" {
+		t.Fatalf("expected only the 15:00 slot short, got %v", rows)
+	}
+}
@@ -0,0 +1,164 @@
+package core
+
+import (
+	"fmt"
+	"math"
+	"strings"
+	"time"
+
+	"github.com/xuri/excelize/v2"
+)
+
+const costSheet = "Cost"
+
+// CostOptions configures the labour cost estimate on the day sheets and the overview
+type CostOptions struct {
+	// Show the estimate, needs hourly wages in the settings file or a default wage
+	Enabled bool `json:"enabled" toml:"enabled" yaml:"enabled"`
+	// Factor on the hourly wage per pay category of the payroll rules, 1 when missing
+	Multipliers map[string]float64 `json:"multipliers" toml:"multipliers" yaml:"multipliers"`
+	// Employer contributions (arbetsgivaravgifter) in percent of the wages
+	EmployerContributions float64 `json:"employerContributions" toml:"employerContributions" yaml:"employerContributions"`
+	// Hourly wage of employees without one in the settings file
+	DefaultWage float64 `json:"defaultWage" toml:"defaultWage" yaml:"defaultWage"`
+	// Row label on the sheets
+	Header string `json:"header" toml:"header" yaml:"header"`
+}
+
+func defaultCostOptions() CostOptions {
+	return CostOptions{
+		Multipliers: map[string]float64{
+			"ob_evening": 1.2,
+			"ob_weekend": 1.5,
+			"ob_holiday": 2,
+			PayOvertime:  1.5,
+		},
+		EmployerContributions: 31.42,
+		Header:                "Kostnad",
+	}
+}
+
+func (c CostOptions) validate() error {
+	if c.EmployerContributions < 0 || c.DefaultWage < 0 {
+		return fmt.Errorf("employerContributions and defaultWage must not be negative")
+	}
+	for category, factor := range c.Multipliers {
+		if factor < 0 {
+			return fmt.Errorf("multiplier of %s must not be negative", category)
+		}
+	}
+	return nil
+}
+
+// multiplier returns the wage factor of a pay category
+func (c CostOptions) multiplier(category string) float64 {
+	if factor, ok := c.Multipliers[category]; ok {
+		return factor
+	}
+	return 1
+}
+
+// laborCost holds the estimated cost per minute of the day, by date
+type laborCost map[string][]float64
+
+/*
+================================================================================
+Estimate the labour cost of every paid minute, contributions included
+================================================================================
+*/
+func estimateLaborCost(shifts []Shift, opts ProcessOptions) (laborCost, error) {
+	contributions := 1 + opts.Cost.EmployerContributions/100
+	costs := laborCost{}
+	err := payMinutes(shifts, opts, payrollHolidays(opts, shifts), func(shift Shift, _ time.Time) func(time.Time, string) {
+		wage := shift.HourlyWage
+		if wage == 0 {
+			wage = opts.Cost.DefaultWage
+		}
+		return func(t time.Time, category string) {
+			// Minutes past midnight count on the next date
+			date := t.Format(time.DateOnly)
+			minutes, ok := costs[date]
+			if !ok {
+				minutes = make([]float64, 24*60)
+				costs[date] = minutes
+			}
+			minutes[t.Hour()*60+t.Minute()] += wage / 60 * opts.Cost.multiplier(category) * contributions
+		}
+	})
+	if err != nil {
+		return nil, err
+	}
+	return costs, nil
+}
+
+// day returns the cost of a date
+func (c laborCost) day(date string) float64 {
+	return c.between(date, 0, 24*60)
+}
+
+// between returns the cost of a date from one minute of the day up to another
+func (c laborCost) between(date string, from, to int) float64 {
+	minutes := c[date]
+	total := 0.0
+	for m := max(from, 0); m < min(to, len(minutes)); m++ {
+		total += minutes[m]
+	}
+	return total
+}
+
+// slots returns the cost of each hour slot header, e.g. "09:00-10:00"
+func (c laborCost) slots(date string, headers []string) []float64 {
+	costs := make([]float64, len(headers))
+	for i, header := range headers {
+		from, to, ok := strings.Cut(header, "-")
+		if !ok {
+			continue
+		}
+		start, err := minuteOfDay(from)
+		if err != nil {
+			continue
+		}
+		end, err := minuteOfDay(to)
+		if err != nil {
+			continue
+		}
+		costs[i] = c.between(date, start, end)
+	}
+	return costs
+}
+
+// roundCost rounds to whole currency units for display
+func roundCost(cost float64) float64 {
+	return math.Round(cost)
+}
+
+/*
+================================================================================
+Cost sheet: the labour cost per day and for the week, written when there is no
+overview to carry the totals
+================================================================================
+*/
+func writeCostSheet(f *excelize.File, week weekView, rc renderContext) error {
+	if _, err := f.NewSheet(costSheet); err != nil {
+		return fmt.Errorf("error creating cost sheet: %v", err)
+	}
+	f.SetSheetRow(costSheet, "A1", &[]interface{}{week.name + " - " + rc.opts.Cost.Header})
+	f.MergeCell(costSheet, "A1", "C1")
+	f.SetCellStyle(costSheet, "A1", "C1", rc.styles.title)
+	f.SetSheetRow(costSheet, "A2", &[]interface{}{rc.opts.DayHeader, rc.opts.DateHeader, rc.opts.Cost.Header})
+	f.SetCellStyle(costSheet, "A2", "C2", rc.styles.header)
+	row := 3
+	weekCost := 0.0
+	for _, day := range week.days {
+		cell, _ := excelize.CoordinatesToCellName(1, row)
+		f.SetSheetRow(costSheet, cell, &[]interface{}{day.data.dayStr, day.data.dateStr, roundCost(day.cost)})
+		weekCost += day.cost
+		row++
+	}
+	first, _ := excelize.CoordinatesToCellName(1, row)
+	last, _ := excelize.CoordinatesToCellName(3, row)
+	f.SetSheetRow(costSheet, first, &[]interface{}{rc.opts.TotalHeader, nil, roundCost(weekCost)})
+	f.SetCellStyle(costSheet, first, last, rc.styles.header)
+	f.SetColWidth(costSheet, "A", "C", 14)
+	return nil
+}
@@ -0,0 +1,143 @@
+package core
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/xuri/excelize/v2"
+)
+
+func TestEstimateLaborCost(t *testing.T) {
+	opts := DefaultProcessOptions()
+	opts.Cost.EmployerContributions = 50
+	opts.Cost.DefaultWage = 100
+	shifts := []Shift{
+		{EmployeeId: 1, Date: "2025-03-17", StartTime: "17:00:00", EndTime: "19:00:00", HourlyWage: 200},
+		{EmployeeId: 2, Date: "2025-03-17", StartTime: "17:00:00", EndTime: "18:00:00"},
+	}
+	costs, err := estimateLaborCost(shifts, opts)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	// 17:00-18:15 regular, then evening OB at 1.2, all plus 50 % contributions
+	evening := 200.0 / 60 * 45 * 1.2 * 1.5
+	want := (200*1.25+100)*1.5 + evening
+	if got := costs.day("2025-03-17"); roundCost(got) != roundCost(want) {
+		t.Fatalf("expected day cost %.0f, got %.0f", want, got)
+	}
+	slots := costs.slots("2025-03-17", []string{"17:00-18:00", "18:00-19:00"})
+	if roundCost(slots[0]) != 450 || roundCost(slots[1]) != roundCost(want-450) {
+		t.Fatalf("unexpected slot costs %v", slots)
+	}
+}
+
+// costSettingsFile has a title row and a header row like the settings export
+func costSettingsFile(t *testing.T) *bytes.Reader {
+	t.Helper()
+	f := excelize.NewFile()
+	defer f.Close()
+	f.SetSheetRow("Sheet1", "A1", &[]interface{}{"Inställningar"})
+	f.SetSheetRow("Sheet1", "A2", &[]interface{}{"Id", "Telefon", "Roll", "Timlön"})
+	f.SetSheetRow("Sheet1", "A3", &[]interface{}{"1", "070", "", "200"})
+	buf, err := f.WriteToBuffer()
+	if err != nil {
+		t.Fatalf("writing settings: %v", err)
+	}
+	return bytes.NewReader(buf.Bytes())
+}
+
+func TestProcessFilesCost(t *testing.T) {
+	opts := DefaultProcessOptions()
+	opts.Overview = true
+	opts.Cost.Enabled = true
+	opts.Cost.EmployerContributions = 0
+	opts.Cost.DefaultWage = 100
+	result, err := ProcessFilesWithOptions(buildInputFile(t, employeeShifts), costSettingsFile(t), nil, opts)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	f, err := excelize.OpenReader(bytes.NewReader(result["Vecka 12.xlsx"]))
+	if err != nil {
+		t.Fatalf("opening result: %v", err)
+	}
+	defer f.Close()
+
+	// Anna works 09-17 with lunch at 14 for 200 an hour, slots start at 10
+	expect := map[string]string{
+		"A5": "Kostnad",
+		"D5": "200",
+		"H5": "0",
+		"K5": "1400",
+	}
+	for cell, value := range expect {
+		if got, _ := f.GetCellValue("Monday", cell); got != value {
+			t.Fatalf("expected %q in Monday!%s, got %q", value, cell, got)
+		}
+	}
+
+	// Erik has no wage and gets the default
+	expect = map[string]string{
+		"A7": "Kostnad",
+		"B7": "1400",
+		"C7": "400",
+		"D7": "800",
+		"I7": "2600",
+	}
+	for cell, value := range expect {
+		if got, _ := f.GetCellValue("Overview", cell); got != value {
+			t.Fatalf("expected %q in Overview!%s, got %q", value, cell, got)
+		}
+	}
+}
+
+func TestProcessFilesCostWithoutOverview(t *testing.T) {
+	opts := DefaultProcessOptions()
+	opts.Cost.Enabled = true
+	opts.Cost.EmployerContributions = 0
+	opts.Cost.DefaultWage = 100
+	result, err := ProcessFilesWithOptions(buildInputFile(t, employeeShifts), costSettingsFile(t), nil, opts)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	f, err := excelize.OpenReader(bytes.NewReader(result["Vecka 12.xlsx"]))
+	if err != nil {
+		t.Fatalf("opening result: %v", err)
+	}
+	defer f.Close()
+
+	// The week total still gets written, on a sheet of its own
+	expect := map[string]string{
+		"A3": "Monday",
+		"C3": "1400",
+		"C5": "800",
+		"A6": opts.TotalHeader,
+		"C6": "2600",
+	}
+	for cell, value := range expect {
+		if got, _ := f.GetCellValue("Cost", cell); got != value {
+			t.Fatalf("expected %q in Cost!%s, got %q", value, cell, got)
+		}
+	}
+}
+
+func TestProcessFilesTextFourthSettingsColumn(t *testing.T) {
+	f := excelize.NewFile()
+	defer f.Close()
+	f.SetSheetRow("Sheet1", "A1", &[]interface{}{"Inställningar"})
+	f.SetSheetRow("Sheet1", "A2", &[]interface{}{"Id", "Telefon", "Roll", "Kommentar"})
+	f.SetSheetRow("Sheet1", "A3", &[]interface{}{"1", "070", "Kassa", "nyanställd"})
+	buf, err := f.WriteToBuffer()
+	if err != nil {
+		t.Fatalf("writing settings: %v", err)
+	}
+
+	// Without the cost estimate the fourth column is not read as a wage
+	opts := DefaultProcessOptions()
+	if _, err := ProcessFilesWithOptions(buildInputFile(t, employeeShifts), bytes.NewReader(buf.Bytes()), nil, opts); err != nil {
+		t.Fatalf("unexpected error with cost disabled: %v", err)
+	}
+	opts.Cost.Enabled = true
+	if _, err := ProcessFilesWithOptions(buildInputFile(t, employeeShifts), bytes.NewReader(buf.Bytes()), nil, opts); err == nil {
+		t.Fatalf("expected a text wage to fail with cost enabled")
+	}
+}
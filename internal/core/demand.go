@@ -0,0 +1,321 @@
+package core
+
+import (
+	"cmp"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/go-gota/gota/dataframe"
+	"github.com/xuri/excelize/v2"
+)
+
+// Name of the weekly staffing gaps sheet
+const demandSheet = "Gaps"
+
+// StaffingOptions configures the comparison of staffing against the demand file
+type StaffingOptions struct {
+	// Transactions one person handles per hour, used for rows without their own ratio
+	Productivity float64 `json:"productivity" toml:"productivity" yaml:"productivity"`
+	// Rows in the weekly table of the worst gaps
+	TopGaps int `json:"topGaps" toml:"topGaps" yaml:"topGaps"`
+	// Row label of the heatmap on the day sheets
+	Header string `json:"header" toml:"header" yaml:"header"`
+	// Heatmap colours from understaffed over even to overstaffed
+	UnderColor string `json:"underColor" toml:"underColor" yaml:"underColor"`
+	EvenColor  string `json:"evenColor" toml:"evenColor" yaml:"evenColor"`
+	OverColor  string `json:"overColor" toml:"overColor" yaml:"overColor"`
+	// Difference in staff shown in the full under or over colour
+	Scale int `json:"scale" toml:"scale" yaml:"scale"`
+	// Column headers of the gaps sheet
+	SlotHeader     string `json:"slotHeader" toml:"slotHeader" yaml:"slotHeader"`
+	RequiredHeader string `json:"requiredHeader" toml:"requiredHeader" yaml:"requiredHeader"`
+	StaffedHeader  string `json:"staffedHeader" toml:"staffedHeader" yaml:"staffedHeader"`
+	DiffHeader     string `json:"diffHeader" toml:"diffHeader" yaml:"diffHeader"`
+}
+
+func defaultStaffingOptions() StaffingOptions {
+	return StaffingOptions{
+		TopGaps:    10,
+		Header:     "Bemanning",
+		UnderColor: "#F8696B",
+		EvenColor:  "#63BE7B",
+		OverColor:  "#FFEB84",
+		Scale:      3,
+
+		SlotHeader:     "Tid",
+		RequiredHeader: "Behov",
+		StaffedHeader:  "Bemannat",
+		DiffHeader:     "Differens",
+	}
+}
+
+func (d StaffingOptions) validate() error {
+	if d.Productivity < 0 || d.TopGaps < 0 {
+		return fmt.Errorf("productivity and topGaps must not be negative")
+	}
+	if d.Scale < 1 {
+		return fmt.Errorf("scale must be at least 1")
+	}
+	for _, color := range []string{d.UnderColor, d.EvenColor, d.OverColor} {
+		if r, _, _ := hexColor(color, -1); r < 0 {
+			return fmt.Errorf("invalid colour %q", color)
+		}
+	}
+	return nil
+}
+
+// demandGap compares the staff needed in one slot with the staff working
+type demandGap struct {
+	date     string
+	day      string
+	start    int // minute of the day
+	required float64
+	staffed  int
+}
+
+func (g demandGap) diff() float64 {
+	return float64(g.staffed) - g.required
+}
+
+// slot names the hour starting at the gap, e.g. "10:00-11:00"
+func (g demandGap) slot() string {
+	return fmt.Sprintf("%02d:%02d-%02d:%02d", g.start/60, g.start%60, (g.start/60+1)%24, g.start%60)
+}
+
+/*
+================================================================================
+Read the demand file: date, slot and required staff, or forecast
+transactions with an optional productivity ratio
+================================================================================
+*/
+func readDemand(r io.Reader, opts StaffingOptions) (map[string]map[int]float64, error) {
+	rows, err := readTable(r)
+	if err != nil {
+		return nil, errors.New("Error reading demand: " + err.Error())
+	}
+	number := func(s string) (float64, error) {
+		return strconv.ParseFloat(strings.ReplaceAll(s, ",", "."), 64)
+	}
+	demand := map[string]map[int]float64{}
+	for i, record := range tableRecords(rows) {
+		date, err := normalizeDate(record["date"])
+		if err != nil {
+			return nil, fmt.Errorf("Error reading demand row %d: %v", i+2, err)
+		}
+		start, err := slotStart(record["slot"])
+		if err != nil {
+			return nil, fmt.Errorf("Error reading demand row %d: %v", i+2, err)
+		}
+		var required float64
+		if record["required"] != "" {
+			if required, err = number(record["required"]); err != nil {
+				return nil, fmt.Errorf("Error reading demand row %d: invalid required %q", i+2, record["required"])
+			}
+		} else {
+			transactions, err := number(record["transactions"])
+			if err != nil {
+				return nil, fmt.Errorf("Error reading demand row %d: needs required or transactions", i+2)
+			}
+			productivity := opts.Productivity
+			if record["productivity"] != "" {
+				if productivity, err = number(record["productivity"]); err != nil {
+					return nil, fmt.Errorf("Error reading demand row %d: invalid productivity %q", i+2, record["productivity"])
+				}
+			}
+			if productivity <= 0 {
+				return nil, fmt.Errorf("Error reading demand row %d: transactions need a productivity ratio", i+2)
+			}
+			required = math.Ceil(transactions / productivity)
+		}
+		if demand[date] == nil {
+			demand[date] = map[int]float64{}
+		}
+		// Rows for the same slot, e.g. one per department, add up
+		demand[date][start] += required
+	}
+	return demand, nil
+}
+
+// slotStart accepts "10", "10:00" or "10:00-11:00" and returns the minute of the day
+func slotStart(slot string) (int, error) {
+	start, _, _ := strings.Cut(strings.TrimSpace(slot), "-")
+	start = strings.TrimSpace(start)
+	if !strings.Contains(start, ":") {
+		start += ":00"
+	}
+	if len(start) == 4 {
+		start = "0" + start
+	}
+	minute, err := minuteOfDay(start)
+	if err != nil || minute >= 24*60 {
+		return 0, fmt.Errorf("invalid slot %q", slot)
+	}
+	return minute, nil
+}
+
+// dayDemand compares the demand of a date with the shifts working at the start of each slot
+func dayDemand(dateDf dataframe.DataFrame, dayData DaySchedule, demand map[int]float64, opts ProcessOptions) ([]demandGap, error) {
+	type span struct {
+		start, end, lunchStart, lunchEnd int
+		absence                          *Absence
+	}
+	spans := []span{}
+	for i := 0; i < dateDf.Nrow(); i++ {
+		start, err := time.Parse(time.TimeOnly, dateDf.Col("startTime").Elem(i).String())
+		if err != nil {
+			return nil, errors.New("Error parsing startTime: " + err.Error())
+		}
+		end, err := time.Parse(time.TimeOnly, dateDf.Col("endTime").Elem(i).String())
+		if err != nil {
+			return nil, errors.New("Error parsing endTime: " + err.Error())
+		}
+		hasLunch, _ := dateDf.Col("hasLunch").Elem(i).Bool()
+		s := span{start: start.Hour()*60 + start.Minute(), end: end.Hour()*60 + end.Minute(), absence: dayData.shifts[i].absence}
+		if s.end <= s.start {
+			s.end += 24 * 60
+		}
+		s.lunchStart, s.lunchEnd = s.start, s.start
+		if hasLunch {
+			s.lunchStart = s.start + int(opts.lunchAfter().Minutes())
+			s.lunchEnd = s.lunchStart + int(opts.LunchHours*60)
+		}
+		spans = append(spans, s)
+	}
+
+	gaps := []demandGap{}
+	for start, required := range demand {
+		gap := demandGap{date: dayData.dateStr, day: dayData.dayStr, start: start, required: required}
+		for _, s := range spans {
+			if s.absence != nil && s.absence.excludes(start, s.start, s.end) {
+				continue
+			}
+			if start >= s.start && start < s.end && (start < s.lunchStart || start >= s.lunchEnd) {
+				gap.staffed++
+			}
+		}
+		gaps = append(gaps, gap)
+	}
+	slices.SortFunc(gaps, func(a, b demandGap) int { return a.start - b.start })
+	return gaps, nil
+}
+
+// unstaffedDemand lists the demand of the dates in the week that have no shifts
+// at all, every slot of them is a gap
+func unstaffedDemand(weekDf dataframe.DataFrame, demand map[string]map[int]float64) []demandGap {
+	dates := weekDf.Col("date").Records()
+	if len(dates) == 0 {
+		return nil
+	}
+	first, err := time.Parse(time.DateOnly, dates[0])
+	if err != nil {
+		return nil
+	}
+	year, week := first.ISOWeek()
+	gaps := []demandGap{}
+	for date, slots := range demand {
+		d, err := time.Parse(time.DateOnly, date)
+		if err != nil || slices.Contains(dates, date) {
+			continue
+		}
+		if y, w := d.ISOWeek(); y != year || w != week {
+			continue
+		}
+		for start, required := range slots {
+			gaps = append(gaps, demandGap{date: date, day: d.Weekday().String(), start: start, required: required})
+		}
+	}
+	return gaps
+}
+
+// writeDemandRow writes staffed minus required under each hour slot and
+// colours the row as a heatmap
+func writeDemandRow(file *excelize.File, sheetName string, view dayView, rc renderContext, layout dayLayout, row int) error {
+	labelCell, err := excelize.CoordinatesToCellName(layout.gridCol, row)
+	if err != nil {
+		return fmt.Errorf("error calculating demand cell: %v", err)
+	}
+	file.SetCellValue(sheetName, labelCell, rc.opts.Staffing.Header)
+	file.SetCellStyle(sheetName, labelCell, labelCell, styleOr(layout.headerStyle, rc.styles.header))
+
+	hourOffset := layout.gridCol + len(rc.opts.headers())
+	slots := view.data.headers[len(rc.opts.headers()):]
+	for i, slot := range slots {
+		start, err := slotStart(slot)
+		if err != nil {
+			continue
+		}
+		for _, gap := range view.demand {
+			if gap.start == start {
+				cell, _ := excelize.CoordinatesToCellName(hourOffset+i, row)
+				file.SetCellValue(sheetName, cell, gap.diff())
+			}
+		}
+	}
+	if len(slots) == 0 {
+		return nil
+	}
+	first, _ := excelize.CoordinatesToCellName(hourOffset, row)
+	last, _ := excelize.CoordinatesToCellName(hourOffset+len(slots)-1, row)
+	scale := strconv.Itoa(rc.opts.Staffing.Scale)
+	return file.SetConditionalFormat(sheetName, first+":"+last, []excelize.ConditionalFormatOptions{{
+		Type:     "3_color_scale",
+		Criteria: "=",
+		MinType:  "num", MinValue: "-" + scale, MinColor: rc.opts.Staffing.UnderColor,
+		MidType: "num", MidValue: "0", MidColor: rc.opts.Staffing.EvenColor,
+		MaxType: "num", MaxValue: scale, MaxColor: rc.opts.Staffing.OverColor,
+	}})
+}
+
+/*
+================================================================================
+Gaps sheet: the worst understaffed slots of the week
+================================================================================
+*/
+func writeDemandSheet(f *excelize.File, week weekView, rc renderContext) error {
+	gaps := []demandGap{}
+	for _, day := range week.days {
+		for _, gap := range day.demand {
+			if gap.diff() < 0 {
+				gaps = append(gaps, gap)
+			}
+		}
+	}
+	for _, gap := range week.unstaffed {
+		if gap.diff() < 0 {
+			gaps = append(gaps, gap)
+		}
+	}
+	slices.SortStableFunc(gaps, func(a, b demandGap) int {
+		if a.diff() != b.diff() {
+			return int(math.Copysign(1, a.diff()-b.diff()))
+		}
+		return cmp.Or(strings.Compare(a.date, b.date), a.start-b.start)
+	})
+	if len(gaps) > rc.opts.Staffing.TopGaps {
+		gaps = gaps[:rc.opts.Staffing.TopGaps]
+	}
+
+	if _, err := f.NewSheet(demandSheet); err != nil {
+		return fmt.Errorf("error creating gaps sheet: %v", err)
+	}
+	staffing := rc.opts.Staffing
+	headers := []string{rc.opts.DayHeader, rc.opts.DateHeader, staffing.SlotHeader, staffing.RequiredHeader, staffing.StaffedHeader, staffing.DiffHeader}
+	f.SetSheetRow(demandSheet, "A1", &[]interface{}{week.name + " - " + rc.opts.Staffing.Header})
+	f.MergeCell(demandSheet, "A1", "F1")
+	f.SetCellStyle(demandSheet, "A1", "F1", rc.styles.title)
+	f.SetSheetRow(demandSheet, "A2", &headers)
+	f.SetCellStyle(demandSheet, "A2", "F2", rc.styles.header)
+	for i, gap := range gaps {
+		cell, _ := excelize.CoordinatesToCellName(1, i+3)
+		f.SetSheetRow(demandSheet, cell, &[]interface{}{gap.day, gap.date, gap.slot(), gap.required, gap.staffed, gap.diff()})
+	}
+	f.SetColWidth(demandSheet, "A", "C", 14)
+	f.SetColWidth(demandSheet, "D", "F", 11)
+	return nil
+}
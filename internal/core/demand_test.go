@@ -0,0 +1,107 @@
+package core
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/xuri/excelize/v2"
+)
+
+const demandCSV = `date;slot;required;transactions;productivity
+2025-03-17;10:00;2;;
+2025-03-17;14:00-15:00;1;;
+2025-03-17;16;0;;
+2025-03-18;11:00;;30;15
+`
+
+func TestReadDemand(t *testing.T) {
+	demand, err := readDemand(strings.NewReader(demandCSV), defaultStaffingOptions())
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if demand["2025-03-17"][10*60] != 2 || demand["2025-03-17"][14*60] != 1 {
+		t.Fatalf("unexpected demand %v", demand["2025-03-17"])
+	}
+	if demand["2025-03-18"][11*60] != 2 {
+		t.Fatalf("expected 30 transactions at 15 an hour to need 2, got %v", demand["2025-03-18"])
+	}
+
+	_, err = readDemand(strings.NewReader("date,slot,transactions\n2025-03-17,10:00,30\n"), defaultStaffingOptions())
+	if err == nil {
+		t.Fatalf("expected transactions without a productivity ratio to fail")
+	}
+	opts := defaultStaffingOptions()
+	opts.Productivity = 10
+	demand, err = readDemand(strings.NewReader("date,slot,transactions\n2025-03-17,10:00,31\n"), opts)
+	if err != nil || demand["2025-03-17"][10*60] != 4 {
+		t.Fatalf("expected the default ratio to round up to 4, got %v (%v)", demand, err)
+	}
+}
+
+func TestProcessFilesDemand(t *testing.T) {
+	opts := DefaultProcessOptions()
+	opts.Demand = strings.NewReader(demandCSV)
+	result, err := ProcessFilesWithOptions(buildInputFile(t, employeeShifts), nil, nil, opts)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	f, err := excelize.OpenReader(bytes.NewReader(result["Vecka 12.xlsx"]))
+	if err != nil {
+		t.Fatalf("opening result: %v", err)
+	}
+	defer f.Close()
+
+	// Anna works 09-17 with lunch at 14, slots start at 10
+	expect := map[string]string{
+		"A5": "Bemanning",
+		"D5": "-1",
+		"E5": "",
+		"H5": "-1",
+		"J5": "1",
+	}
+	for cell, value := range expect {
+		if got, _ := f.GetCellValue("Monday", cell); got != value {
+			t.Fatalf("expected %q in Monday!%s, got %q", value, cell, got)
+		}
+	}
+	formats, err := f.GetConditionalFormats("Monday")
+	if err != nil || len(formats["D5:J5"]) != 1 || formats["D5:J5"][0].Type != "3_color_scale" {
+		t.Fatalf("expected a colour scale on the demand row, got %v (%v)", formats, err)
+	}
+
+	if sheets := f.GetSheetList(); sheets[len(sheets)-1] != "Gaps" {
+		t.Fatalf("expected a gaps sheet after the days, got %v", sheets)
+	}
+	rows, _ := f.GetRows("Gaps")
+	if len(rows) != 5 {
+		t.Fatalf("expected title, header and three gaps, got %v", rows)
+	}
+	if rows[2][0] != "Monday" || rows[2][2] != "10:00-11:00" || rows[2][5] != "-1" || rows[4][2] != "11:00-12:00" {
+		t.Fatalf("unexpected gaps %v", rows[2:])
+	}
+}
+
+func TestProcessFilesDemandUnstaffedDate(t *testing.T) {
+	opts := DefaultProcessOptions()
+	// Nobody works Thursday, the Monday after belongs to the next week
+	opts.Demand = strings.NewReader("date,slot,required\n2025-03-20,10:00,2\n2025-03-24,10:00,1\n")
+	opts.Staffing.DiffHeader = "Diff"
+	result, err := ProcessFilesWithOptions(buildInputFile(t, employeeShifts), nil, nil, opts)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	f, err := excelize.OpenReader(bytes.NewReader(result["Vecka 12.xlsx"]))
+	if err != nil {
+		t.Fatalf("opening result: %v", err)
+	}
+	defer f.Close()
+
+	rows, _ := f.GetRows("Gaps")
+	if len(rows) != 3 || rows[2][0] != "Thursday" || rows[2][1] != "2025-03-20" || rows[2][4] != "0" || rows[2][5] != "-2" {
+		t.Fatalf("expected the unstaffed Thursday as the only gap, got %v", rows)
+	}
+	if rows[1][2] != "Tid" || rows[1][5] != "Diff" {
+		t.Fatalf("expected the configured headers, got %v", rows[1])
+	}
+}
@@ -0,0 +1,351 @@
+package core
+
+import (
+	"bytes"
+	"cmp"
+	"errors"
+	"fmt"
+	"io"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/go-gota/gota/dataframe"
+	"github.com/xuri/excelize/v2"
+)
+
+// Kinds of shift changes between two exports
+const (
+	ChangeAdded   = "added"
+	ChangeRemoved = "removed"
+	ChangeMoved   = "moved"
+	ChangeRole    = "role"
+)
+
+// DiffOptions names the change report
+type DiffOptions struct {
+	// Report file written next to the schedules when a previous export is given
+	FileName    string `json:"fileName" toml:"fileName" yaml:"fileName"`
+	Sheet       string `json:"sheet" toml:"sheet" yaml:"sheet"`
+	NotifySheet string `json:"notifySheet" toml:"notifySheet" yaml:"notifySheet"`
+	// Shown for each kind of change
+	Labels map[string]string `json:"labels" toml:"labels" yaml:"labels"`
+	// Column headers of the sheets
+	ChangeHeader string `json:"changeHeader" toml:"changeHeader" yaml:"changeHeader"`
+	BeforeHeader string `json:"beforeHeader" toml:"beforeHeader" yaml:"beforeHeader"`
+	AfterHeader  string `json:"afterHeader" toml:"afterHeader" yaml:"afterHeader"`
+	IdHeader     string `json:"idHeader" toml:"idHeader" yaml:"idHeader"`
+}
+
+func defaultDiffOptions() DiffOptions {
+	return DiffOptions{
+		FileName:    "Ändringar",
+		Sheet:       "Ändringar",
+		NotifySheet: "Meddela",
+		Labels: map[string]string{
+			ChangeAdded:   "Nytt pass",
+			ChangeRemoved: "Borttaget",
+			ChangeMoved:   "Ny tid",
+			ChangeRole:    "Ny roll",
+		},
+		ChangeHeader: "Ändring",
+		BeforeHeader: "Före",
+		AfterHeader:  "Efter",
+		IdHeader:     "Id",
+	}
+}
+
+func (d DiffOptions) validate() error {
+	if d.FileName == "" || d.Sheet == "" || d.NotifySheet == "" || d.Sheet == d.NotifySheet {
+		return errors.New("fileName, sheet and notifySheet must be set and the sheets differ")
+	}
+	return nil
+}
+
+// label falls back to the kind when no label is configured
+func (d DiffOptions) label(kind string) string {
+	return cmp.Or(d.Labels[kind], kind)
+}
+
+// ShiftChange is one difference between two exports of the same period
+type ShiftChange struct {
+	Kind       string `json:"kind"`
+	EmployeeId int    `json:"employeeId"`
+	Name       string `json:"name"`
+	Date       string `json:"date"`
+	Before     string `json:"before,omitempty"` // time in the previous export
+	After      string `json:"after,omitempty"`  // time in the new export
+	OldRole    string `json:"oldRole,omitempty"`
+	NewRole    string `json:"newRole,omitempty"`
+}
+
+/*
+================================================================================
+Match the shifts of two exports by employee and date and list what was added,
+removed, moved or given another role
+================================================================================
+*/
+func DiffShifts(before []Shift, after []Shift) []ShiftChange {
+	type key struct {
+		id   int
+		date string
+	}
+	group := func(shifts []Shift) map[key][]Shift {
+		groups := map[key][]Shift{}
+		for _, s := range shifts {
+			k := key{s.EmployeeId, s.Date}
+			groups[k] = append(groups[k], s)
+		}
+		for _, g := range groups {
+			slices.SortStableFunc(g, func(a, b Shift) int { return strings.Compare(a.StartTime, b.StartTime) })
+		}
+		return groups
+	}
+	old, updated := group(before), group(after)
+	keys := map[key]bool{}
+	for k := range old {
+		keys[k] = true
+	}
+	for k := range updated {
+		keys[k] = true
+	}
+
+	changes := []ShiftChange{}
+	for k := range keys {
+		o, n := old[k], updated[k]
+		for _, p := range pairShifts(o, n) {
+			i, j := p[0], p[1]
+			change := ShiftChange{EmployeeId: k.id, Date: k.date}
+			switch {
+			case i < 0:
+				change.Kind, change.Name, change.After, change.NewRole = ChangeAdded, n[j].Name(), n[j].Time, n[j].Role
+				changes = append(changes, change)
+			case j < 0:
+				change.Kind, change.Name, change.Before, change.OldRole = ChangeRemoved, o[i].Name(), o[i].Time, o[i].Role
+				changes = append(changes, change)
+			default:
+				change.Name, change.Before, change.After = n[j].Name(), o[i].Time, n[j].Time
+				if o[i].StartTime != n[j].StartTime || o[i].EndTime != n[j].EndTime {
+					change.Kind = ChangeMoved
+					changes = append(changes, change)
+				}
+				if o[i].Role != n[j].Role {
+					change.Kind, change.OldRole, change.NewRole = ChangeRole, o[i].Role, n[j].Role
+					changes = append(changes, change)
+				}
+			}
+		}
+	}
+	kinds := []string{ChangeAdded, ChangeRemoved, ChangeMoved, ChangeRole}
+	slices.SortFunc(changes, func(a, b ShiftChange) int {
+		return cmp.Or(strings.Compare(a.Date, b.Date), strings.Compare(a.Name, b.Name), a.EmployeeId-b.EmployeeId,
+			strings.Compare(cmp.Or(a.Before, a.After), cmp.Or(b.Before, b.After)),
+			slices.Index(kinds, a.Kind)-slices.Index(kinds, b.Kind))
+	})
+	return changes
+}
+
+// pairShifts matches the shifts of one employee and date by index, shifts with
+// the same times first and then the closest starts. The index of the other side
+// is -1 for a shift that was added or removed
+func pairShifts(old []Shift, updated []Shift) [][2]int {
+	usedOld, usedNew := make([]bool, len(old)), make([]bool, len(updated))
+	pairs := [][2]int{}
+	for i, o := range old {
+		for j, n := range updated {
+			if !usedNew[j] && o.StartTime == n.StartTime && o.EndTime == n.EndTime {
+				usedOld[i], usedNew[j] = true, true
+				pairs = append(pairs, [2]int{i, j})
+				break
+			}
+		}
+	}
+	start := func(s Shift) int {
+		t, _ := time.Parse(time.TimeOnly, s.StartTime)
+		return t.Hour()*60 + t.Minute()
+	}
+	for {
+		best, distance := [2]int{-1, -1}, 0
+		for i, o := range old {
+			for j, n := range updated {
+				if usedOld[i] || usedNew[j] {
+					continue
+				}
+				d := start(o) - start(n)
+				if d < 0 {
+					d = -d
+				}
+				if best[0] < 0 || d < distance {
+					best, distance = [2]int{i, j}, d
+				}
+			}
+		}
+		if best[0] < 0 {
+			break
+		}
+		usedOld[best[0]], usedNew[best[1]] = true, true
+		pairs = append(pairs, best)
+	}
+	for i := range old {
+		if !usedOld[i] {
+			pairs = append(pairs, [2]int{i, -1})
+		}
+	}
+	for j := range updated {
+		if !usedNew[j] {
+			pairs = append(pairs, [2]int{-1, j})
+		}
+	}
+	return pairs
+}
+
+// NotifyEmployee is someone with changed shifts
+type NotifyEmployee struct {
+	EmployeeId int    `json:"employeeId"`
+	Name       string `json:"name"`
+	Changes    int    `json:"changes"`
+}
+
+// Notify lists the employees with changed shifts by name
+func Notify(changes []ShiftChange) []NotifyEmployee {
+	employees := []NotifyEmployee{}
+	for _, c := range changes {
+		i := slices.IndexFunc(employees, func(e NotifyEmployee) bool { return e.EmployeeId == c.EmployeeId })
+		if i < 0 {
+			employees = append(employees, NotifyEmployee{EmployeeId: c.EmployeeId, Name: c.Name})
+			i = len(employees) - 1
+		}
+		employees[i].Changes++
+	}
+	slices.SortFunc(employees, func(a, b NotifyEmployee) int {
+		return cmp.Or(strings.Compare(a.Name, b.Name), a.EmployeeId-b.EmployeeId)
+	})
+	return employees
+}
+
+// DiffFiles reads two exports of the same period with the same settings and compares them
+func DiffFiles(before io.Reader, after io.Reader, settings io.Reader, opts ProcessOptions) ([]ShiftChange, error) {
+	if err := opts.Validate(); err != nil {
+		return nil, errors.New("Invalid options: " + err.Error())
+	}
+	settingsDf, err := readSettingsFile(settings)
+	if err != nil {
+		return nil, err
+	}
+	old, err := previousShifts(before, settingsDf, &opts)
+	if err != nil {
+		return nil, err
+	}
+	df, err := readAndRefineInputData(after, settingsDf, opts)
+	if err != nil {
+		return nil, errors.New("Error reading input data: " + err.Error())
+	}
+	updated, err := shiftsFromDataFrame(df)
+	if err != nil {
+		return nil, err
+	}
+	return DiffShifts(old, updated), nil
+}
+
+// previousShifts reads an earlier export like the current one, both get the
+// same base schedule merged in
+func previousShifts(previous io.Reader, settingsDf dataframe.DataFrame, opts *ProcessOptions) ([]Shift, error) {
+	prevOpts := *opts
+	if opts.BaseSchedule != nil {
+		base, err := io.ReadAll(opts.BaseSchedule)
+		if err != nil {
+			return nil, errors.New("Error reading base schedule: " + err.Error())
+		}
+		opts.BaseSchedule, prevOpts.BaseSchedule = bytes.NewReader(base), bytes.NewReader(base)
+	}
+	df, err := readAndRefineInputData(previous, settingsDf, prevOpts)
+	if err != nil {
+		return nil, errors.New("Error reading previous export: " + err.Error())
+	}
+	return shiftsFromDataFrame(df)
+}
+
+// changedShifts indexes the changes by what they look like in the new export
+func changedShifts(changes []ShiftChange) map[string]string {
+	changed := map[string]string{}
+	for _, c := range changes {
+		if c.Kind != ChangeRemoved {
+			// A moved shift with a new role stays moved
+			k := strconv.Itoa(c.EmployeeId) + "|" + c.Date + "|" + c.After
+			changed[k] = cmp.Or(changed[k], c.Kind)
+		}
+	}
+	return changed
+}
+
+/*
+================================================================================
+Write the change report: one sheet with the changes and one with the
+employees to notify
+================================================================================
+*/
+func WriteDiffReport(changes []ShiftChange, opts ProcessOptions) ([]byte, error) {
+	theme, err := resolveTheme(opts.Theme)
+	if err != nil {
+		return nil, errors.New("Error resolving theme: " + err.Error())
+	}
+	f := excelize.NewFile()
+	defer f.Close()
+	styles, err := setStyles(f, theme)
+	if err != nil {
+		return nil, err
+	}
+	cell := func(col, row int) string {
+		name, _ := excelize.CoordinatesToCellName(col, row)
+		return name
+	}
+	table := func(sheet string, headers []string, rows [][]any) {
+		for i, header := range headers {
+			f.SetCellValue(sheet, cell(i+1, 1), header)
+		}
+		f.SetCellStyle(sheet, cell(1, 1), cell(len(headers), 1), styles.header)
+		for i, row := range rows {
+			for j, v := range row {
+				f.SetCellValue(sheet, cell(j+1, i+2), v)
+			}
+		}
+		f.SetColWidth(sheet, "A", "B", 14)
+		f.SetColWidth(sheet, "C", "F", 25)
+	}
+
+	diff := opts.Diff
+	if err := f.SetSheetName("Sheet1", diff.Sheet); err != nil {
+		return nil, err
+	}
+	rows := [][]any{}
+	for _, c := range changes {
+		before, after := c.Before, c.After
+		if c.Kind == ChangeRole {
+			before, after = c.OldRole, c.NewRole
+		}
+		date, _ := time.Parse(time.DateOnly, c.Date)
+		rows = append(rows, []any{diff.label(c.Kind), c.Date, date.Weekday().String(), c.Name, before, after})
+	}
+	table(diff.Sheet, []string{diff.ChangeHeader, opts.DateHeader, opts.DayHeader, opts.NameHeader, diff.BeforeHeader, diff.AfterHeader}, rows)
+	for i, c := range changes {
+		if c.Kind != ChangeRemoved {
+			f.SetCellStyle(diff.Sheet, cell(6, i+2), cell(6, i+2), styles.changed)
+		}
+	}
+
+	if _, err := f.NewSheet(diff.NotifySheet); err != nil {
+		return nil, err
+	}
+	rows = [][]any{}
+	for _, e := range Notify(changes) {
+		rows = append(rows, []any{e.Name, e.EmployeeId, e.Changes})
+	}
+	table(diff.NotifySheet, []string{opts.NameHeader, diff.IdHeader, diff.Sheet}, rows)
+
+	buf, err := f.WriteToBuffer()
+	if err != nil {
+		return nil, fmt.Errorf("error writing to buffer: %v", err)
+	}
+	return buf.Bytes(), nil
+}
@@ -0,0 +1,121 @@
+package core
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/xuri/excelize/v2"
+)
+
+func TestDiffShifts(t *testing.T) {
+	shift := func(id int, date, start, end, role string) Shift {
+		return Shift{EmployeeId: id, FirstName: "Id", LastName: string(rune('0' + id)), Date: date,
+			Time: start[:5] + " - " + end[:5], StartTime: start, EndTime: end, Role: role}
+	}
+	before := []Shift{
+		shift(1, "2025-03-17", "09:00:00", "17:00:00", "Kassa"),
+		shift(2, "2025-03-18", "10:00:00", "14:00:00", ""),
+		shift(3, "2025-03-18", "08:00:00", "12:00:00", ""),
+	}
+	after := []Shift{
+		shift(1, "2025-03-17", "10:00:00", "18:00:00", "Lager"),
+		shift(3, "2025-03-18", "08:00:00", "12:00:00", ""),
+		shift(3, "2025-03-18", "17:00:00", "20:00:00", ""),
+	}
+	got := []string{}
+	for _, c := range DiffShifts(before, after) {
+		got = append(got, c.Kind+" "+c.Date+" "+c.Name+" "+c.Before+">"+c.After+" "+c.OldRole+">"+c.NewRole)
+	}
+	want := []string{
+		"moved 2025-03-17 Id 1 09:00 - 17:00>10:00 - 18:00 >",
+		"role 2025-03-17 Id 1 09:00 - 17:00>10:00 - 18:00 Kassa>Lager",
+		"removed 2025-03-18 Id 2 10:00 - 14:00> >",
+		"added 2025-03-18 Id 3 >17:00 - 20:00 >",
+	}
+	if len(got) != len(want) {
+		t.Fatalf("unexpected changes %q", got)
+	}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Fatalf("change %d: expected %q, got %q", i, want[i], got[i])
+		}
+	}
+
+	notify := Notify(DiffShifts(before, after))
+	if len(notify) != 3 || notify[0].EmployeeId != 1 || notify[0].Changes != 2 {
+		t.Fatalf("unexpected employees to notify %+v", notify)
+	}
+}
+
+func TestDiffShiftsSplitDay(t *testing.T) {
+	shift := func(start, end string) Shift {
+		return Shift{EmployeeId: 1, FirstName: "Anna", LastName: "Svensson", Date: "2025-03-17",
+			Time: start[:5] + " - " + end[:5], StartTime: start, EndTime: end}
+	}
+	// The morning shift goes, the afternoon stays and the evening moves a little
+	before := []Shift{shift("09:00:00", "12:00:00"), shift("14:00:00", "17:00:00"), shift("18:00:00", "20:00:00")}
+	after := []Shift{shift("14:00:00", "17:00:00"), shift("18:30:00", "20:30:00")}
+	changes := DiffShifts(before, after)
+	if len(changes) != 2 || changes[0].Kind != ChangeRemoved || changes[0].Before != "09:00 - 12:00" ||
+		changes[1].Kind != ChangeMoved || changes[1].Before != "18:00 - 20:00" || changes[1].After != "18:30 - 20:30" {
+		t.Fatalf("unexpected changes %+v", changes)
+	}
+	if changed := changedShifts(changes); changed["1|2025-03-17|14:00 - 17:00"] != "" {
+		t.Fatalf("expected the kept shift to stay unmarked, got %v", changed)
+	}
+	if notify := Notify(changes); len(notify) != 1 || notify[0].Changes != 2 {
+		t.Fatalf("unexpected employees to notify %+v", notify)
+	}
+}
+
+func TestProcessFilesPrevious(t *testing.T) {
+	opts := DefaultProcessOptions()
+	opts.Previous = buildInputFile(t, employeeShifts)
+	updated := buildInputFile(t, [][]string{
+		{"1", "Svensson", "Anna", "Pass", "2025-03-17", "09:00 - 17:00", "Kassa"},
+		{"2", "Berg", "Erik", "Pass", "2025-03-18", "11:00 - 15:00", "Kassa"},
+	})
+	result, err := ProcessFilesWithOptions(updated, nil, nil, opts)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if result["Ändringar.xlsx"] == nil {
+		t.Fatalf("expected a change report, got %d files", len(result))
+	}
+
+	f, err := excelize.OpenReader(bytes.NewReader(result["Vecka 12.xlsx"]))
+	if err != nil {
+		t.Fatalf("opening result: %v", err)
+	}
+	defer f.Close()
+	unchanged, _ := f.GetCellStyle("Monday", "A3")
+	moved, _ := f.GetCellStyle("Tuesday", "A3")
+	if moved == unchanged || moved == 0 {
+		t.Fatalf("expected the moved shift highlighted, got styles %d and %d", moved, unchanged)
+	}
+
+	report, err := excelize.OpenReader(bytes.NewReader(result["Ändringar.xlsx"]))
+	if err != nil {
+		t.Fatalf("opening report: %v", err)
+	}
+	defer report.Close()
+	rows, _ := report.GetRows("Ändringar")
+	if len(rows) != 3 || rows[1][0] != "Ny tid" || rows[1][5] != "11:00 - 15:00" || rows[2][0] != "Borttaget" {
+		t.Fatalf("unexpected changes %v", rows)
+	}
+	if rows[0][0] != "Ändring" || rows[0][4] != "Före" || rows[0][5] != "Efter" {
+		t.Fatalf("unexpected headers %v", rows[0])
+	}
+	rows, _ = report.GetRows("Meddela")
+	if len(rows) != 3 || rows[1][0] != "Anna Svensson" || rows[2][0] != "Erik Berg" {
+		t.Fatalf("unexpected employees to notify %v", rows)
+	}
+}
+
+func TestDiffFilesInvalidSettings(t *testing.T) {
+	_, err := DiffFiles(buildInputFile(t, employeeShifts), buildInputFile(t, employeeShifts), strings.NewReader("not a workbook"), DefaultProcessOptions())
+	if err == nil || !strings.Contains(err.Error(), "settings") {
+		t.Fatalf("expected an unreadable settings file to fail, got %v", err)
+	}
+}
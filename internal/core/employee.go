@@ -0,0 +1,189 @@
+package core
+
+import (
+	"errors"
+	"fmt"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/go-gota/gota/dataframe"
+	"github.com/xuri/excelize/v2"
+)
+
+// Employee view modes
+const (
+	EmployeeViewSheets    = "sheets"    // one sheet per employee in the week workbook
+	EmployeeViewWorkbooks = "workbooks" // one workbook per employee and week
+)
+
+// employeeDay is one row of an employee week, shift is nil on days off
+type employeeDay struct {
+	day   string
+	date  string
+	shift *ShiftActivity
+}
+
+// employeeWeek holds the shifts of one employee, Monday to Sunday
+type employeeWeek struct {
+	id    int
+	name  string
+	days  []employeeDay
+	total float64
+}
+
+/*
+================================================================================
+Group the shifts of a week by employee on a common set of hour slots
+================================================================================
+*/
+func employeeWeeks(weekDf dataframe.DataFrame, opts ProcessOptions) ([]employeeWeek, []string, error) {
+	weekDf = weekDf.Arrange(dataframe.Sort("date"), dataframe.Sort("startTime"))
+	// Parsing the whole week as one day gives slots covering every shift
+	data, err := parseDayData(weekDf, opts)
+	if err != nil {
+		return nil, nil, errors.New("error getting week schedule: " + err.Error())
+	}
+	dates := weekDf.Col("date").Records()
+	monday, err := time.Parse(time.DateOnly, dates[0])
+	if err != nil {
+		return nil, nil, errors.New("Error parsing date: " + err.Error())
+	}
+	monday = monday.AddDate(0, 0, -(int(monday.Weekday())+6)%7)
+
+	shiftsById := map[int][]int{}
+	for i, shift := range data.shifts {
+		shiftsById[shift.employeeId] = append(shiftsById[shift.employeeId], i)
+	}
+	weeks := []employeeWeek{}
+	for id, rows := range shiftsById {
+		week := employeeWeek{id: id, name: data.shifts[rows[0]].employeeName}
+		for d := 0; d < 7; d++ {
+			date := monday.AddDate(0, 0, d)
+			dateStr := date.Format(time.DateOnly)
+			worked := false
+			for _, i := range rows {
+				if dates[i] == dateStr {
+					week.days = append(week.days, employeeDay{day: date.Weekday().String(), date: dateStr, shift: &data.shifts[i]})
+					hours, _ := strconv.ParseFloat(data.shifts[i].shiftLength, 64)
+					week.total += hours
+					worked = true
+				}
+			}
+			if !worked {
+				week.days = append(week.days, employeeDay{day: date.Weekday().String(), date: dateStr})
+			}
+		}
+		weeks = append(weeks, week)
+	}
+	slices.SortFunc(weeks, func(a, b employeeWeek) int {
+		if c := strings.Compare(a.name, b.name); c != 0 {
+			return c
+		}
+		return a.id - b.id
+	})
+	return weeks, data.headers[len(opts.headers()):], nil
+}
+
+// employeeSheetName is unique per employee and fits the 31 character limit
+func employeeSheetName(week employeeWeek) string {
+	name := []rune(strconv.Itoa(week.id) + " " + strings.NewReplacer(
+		":", "", `\`, "", "/", "", "?", "", "*", "", "[", "", "]", "", "'", "").Replace(week.name))
+	if len(name) > 31 {
+		name = name[:31]
+	}
+	return strings.TrimSpace(string(name))
+}
+
+/*
+================================================================================
+Write one employee week: a row per day, the hour slots and the week total
+================================================================================
+*/
+func writeEmployeeSheet(f *excelize.File, sheet string, week employeeWeek, slots []string, title string, rc renderContext) error {
+	headers := append([]string{rc.opts.DayHeader, rc.opts.DateHeader, rc.opts.TimeHeader}, slots...)
+	headers = append(headers, rc.opts.TotalHeader)
+	lastCol := len(headers)
+	cell := func(col, row int) string {
+		name, _ := excelize.CoordinatesToCellName(col, row)
+		return name
+	}
+
+	// Title
+	if err := f.MergeCell(sheet, cell(1, 1), cell(lastCol, 1)); err != nil {
+		return fmt.Errorf("error merging cells: %v", err)
+	}
+	f.SetCellValue(sheet, cell(1, 1), week.name+" - "+title)
+	f.SetCellStyle(sheet, cell(1, 1), cell(lastCol, 1), rc.styles.title)
+
+	// Header row
+	for i, header := range headers {
+		f.SetCellValue(sheet, cell(i+1, 2), header)
+	}
+	f.SetCellStyle(sheet, cell(1, 2), cell(lastCol, 2), rc.styles.header)
+
+	// A row per shift, or an empty row on days off
+	row := 3
+	for _, day := range week.days {
+		f.SetCellValue(sheet, cell(1, row), day.day)
+		f.SetCellValue(sheet, cell(2, row), day.date)
+		f.SetCellStyle(sheet, cell(1, row), cell(2, row), rc.styles.name)
+		for slot := range slots {
+			state, role := StateFree, ""
+			if day.shift != nil {
+				state, role = day.shift.hourSchedule[slot], day.shift.role
+			}
+			switch state {
+			case StateLunch:
+				f.SetCellValue(sheet, cell(4+slot, row), "Lunch")
+			case StateAssigned:
+				f.SetCellValue(sheet, cell(4+slot, row), role)
+			}
+			f.SetCellStyle(sheet, cell(4+slot, row), cell(4+slot, row), rc.styles.activity(state, role))
+		}
+		if day.shift != nil {
+			f.SetCellValue(sheet, cell(3, row), day.shift.shiftTime)
+			hours, _ := strconv.ParseFloat(day.shift.shiftLength, 64)
+			f.SetCellValue(sheet, cell(lastCol, row), hours)
+		}
+		row++
+	}
+
+	// Week total
+	f.SetCellValue(sheet, cell(1, row), rc.opts.TotalHeader)
+	f.SetCellValue(sheet, cell(lastCol, row), week.total)
+	f.SetCellStyle(sheet, cell(1, row), cell(lastCol, row), rc.styles.header)
+
+	f.SetColWidth(sheet, "A", "B", 12)
+	f.SetColWidth(sheet, "C", "C", 15)
+	if len(slots) > 0 {
+		first, _ := excelize.ColumnNumberToName(4)
+		last, _ := excelize.ColumnNumberToName(3 + len(slots))
+		f.SetColWidth(sheet, first, last, 12)
+	}
+	return nil
+}
+
+// createEmployeeWorkbook renders one employee week into its own workbook
+func createEmployeeWorkbook(week employeeWeek, slots []string, title string, rc renderContext) ([]byte, error) {
+	f := excelize.NewFile()
+	defer f.Close()
+	styles, err := setStyles(f, rc.theme)
+	if err != nil {
+		return nil, err
+	}
+	rc.styles = styles
+	sheet := employeeSheetName(week)
+	if err := f.SetSheetName("Sheet1", sheet); err != nil {
+		return nil, err
+	}
+	if err := writeEmployeeSheet(f, sheet, week, slots, title, rc); err != nil {
+		return nil, err
+	}
+	buf, err := f.WriteToBuffer()
+	if err != nil {
+		return nil, fmt.Errorf("error writing to buffer: %v", err)
+	}
+	return buf.Bytes(), nil
+}
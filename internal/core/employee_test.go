@@ -0,0 +1,79 @@
+package core
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/xuri/excelize/v2"
+)
+
+var employeeShifts = [][]string{
+	{"1", "Svensson", "Anna", "Pass", "2025-03-17", "09:00 - 17:00", "Kassa"},
+	{"1", "Svensson", "Anna", "Pass", "2025-03-19", "12:00 - 16:00", "Kassa"},
+	{"2", "Berg", "Erik", "Pass", "2025-03-18", "10:00 - 14:00", "Kassa"},
+}
+
+func TestProcessFilesEmployeeSheets(t *testing.T) {
+	opts := DefaultProcessOptions()
+	opts.EmployeeView = EmployeeViewSheets
+	result, err := ProcessFilesWithOptions(buildInputFile(t, employeeShifts), nil, nil, opts)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	f, err := excelize.OpenReader(bytes.NewReader(result["Vecka 12.xlsx"]))
+	if err != nil {
+		t.Fatalf("opening result: %v", err)
+	}
+	defer f.Close()
+
+	sheets := f.GetSheetList()
+	want := []string{"Monday", "Tuesday", "Wednesday", "1 Anna Svensson", "2 Erik Berg"}
+	if len(sheets) != len(want) {
+		t.Fatalf("expected sheets %v, got %v", want, sheets)
+	}
+	for i := range want {
+		if sheets[i] != want[i] {
+			t.Fatalf("expected sheets %v, got %v", want, sheets)
+		}
+	}
+
+	// Slots span the whole week from 10:00 (hideBefore) to 17:00
+	expect := map[string]string{
+		"A1":  "Anna Svensson - Vecka 12",
+		"D2":  "10:00-11:00",
+		"A3":  "Monday",
+		"C3":  "09:00 - 17:00",
+		"H3":  "Lunch",
+		"K3":  "7",
+		"A4":  "Tuesday",
+		"C4":  "",
+		"C5":  "12:00 - 16:00",
+		"A9":  "Sunday",
+		"A10": "Timmar",
+		"K10": "11",
+	}
+	for cell, value := range expect {
+		if got, _ := f.GetCellValue("1 Anna Svensson", cell); got != value {
+			t.Fatalf("expected %q in %s, got %q", value, cell, got)
+		}
+	}
+}
+
+func TestProcessFilesEmployeeWorkbooks(t *testing.T) {
+	opts := DefaultProcessOptions()
+	opts.EmployeeView = EmployeeViewWorkbooks
+	result, err := ProcessFilesWithOptions(buildInputFile(t, employeeShifts), nil, nil, opts)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	for _, name := range []string{"Vecka 12.xlsx", "Vecka 12 - Anna Svensson 1.xlsx", "Vecka 12 - Erik Berg 2.xlsx"} {
+		if len(result[name]) == 0 {
+			t.Fatalf("expected %s in result", name)
+		}
+	}
+
+	opts.EmployeeView = "everyone"
+	if err := opts.Validate(); err == nil {
+		t.Fatalf("expected error for unknown employee view")
+	}
+}
@@ -0,0 +1,212 @@
+package core
+
+import (
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/go-gota/gota/dataframe"
+)
+
+// ExportVersion is bumped whenever the JSON layout changes incompatibly
+const ExportVersion = 1
+
+// ShiftExport is the layout of shifts.json, described by shifts.schema.json
+type ShiftExport struct {
+	Version int       `json:"version"`
+	Shifts  []Shift   `json:"shifts"`
+	Days    []DayGrid `json:"days"`
+}
+
+// DayGrid is the hourly grid of one day as shown on the day sheet
+type DayGrid struct {
+	Date    string    `json:"date"`
+	Weekday string    `json:"weekday"`
+	Week    int       `json:"week"`
+	Slots   []string  `json:"slots"` // e.g. "10:00-11:00"
+	Rows    []GridRow `json:"rows"`
+}
+
+// GridRow is one shift of a day, States has one entry per slot
+type GridRow struct {
+	EmployeeId int      `json:"employeeId"`
+	Name       string   `json:"name"`
+	Time       string   `json:"time"`
+	Role       string   `json:"role"`
+	States     []string `json:"states"` // free, work, lunch or assigned
+}
+
+// String names the state as used in the exports
+func (a HourActivity) String() string {
+	switch a {
+	case StateWork:
+		return "work"
+	case StateLunch:
+		return "lunch"
+	case StateAssigned:
+		return "assigned"
+	}
+	return "free"
+}
+
+// shiftsSchema is a JSON Schema for ShiftExport, written next to shifts.json
+const shiftsSchema = `{
+  "$schema": "https://json-schema.org/draft/2020-12/schema",
+  "title": "Refined shifts and hourly grid",
+  "type": "object",
+  "required": ["version", "shifts", "days"],
+  "properties": {
+    "version": {"const": 1},
+    "shifts": {
+      "type": "array",
+      "items": {
+        "type": "object",
+        "properties": {
+          "employeeId": {"type": "integer"},
+          "firstName": {"type": "string"},
+          "lastName": {"type": "string"},
+          "shiftType": {"type": "string"},
+          "department": {"type": "string"},
+          "date": {"type": "string", "format": "date"},
+          "weekNumber": {"type": "integer", "description": "ISO week"},
+          "time": {"type": "string", "description": "as exported, e.g. 09:00 - 17:00"},
+          "startTime": {"type": "string", "description": "HH:MM:SS"},
+          "endTime": {"type": "string", "description": "HH:MM:SS"},
+          "shiftLength": {"type": "number", "description": "hours, lunch deducted"},
+          "hasLunch": {"type": "boolean"},
+          "role": {"type": "string", "description": "from the settings file"},
+          "phone": {"type": "string", "description": "from the settings file"}
+        }
+      }
+    },
+    "days": {
+      "type": "array",
+      "items": {
+        "type": "object",
+        "properties": {
+          "date": {"type": "string", "format": "date"},
+          "weekday": {"type": "string"},
+          "week": {"type": "integer"},
+          "slots": {"type": "array", "items": {"type": "string", "description": "HH:MM-HH:MM"}},
+          "rows": {
+            "type": "array",
+            "items": {
+              "type": "object",
+              "properties": {
+                "employeeId": {"type": "integer"},
+                "name": {"type": "string"},
+                "time": {"type": "string"},
+                "role": {"type": "string"},
+                "states": {
+                  "type": "array",
+                  "description": "one entry per slot",
+                  "items": {"enum": ["free", "work", "lunch", "assigned"]}
+                }
+              }
+            }
+          }
+        }
+      }
+    }
+  }
+}
+`
+
+/*
+================================================================================
+Machine readable exports of the refined data
+================================================================================
+*/
+func createDataExports(df dataframe.DataFrame, opts ProcessOptions) (map[string][]byte, error) {
+	shifts, err := shiftsFromDataFrame(df)
+	if err != nil {
+		return nil, err
+	}
+	slices.SortStableFunc(shifts, func(a, b Shift) int {
+		if c := strings.Compare(a.Date+a.StartTime, b.Date+b.StartTime); c != 0 {
+			return c
+		}
+		return a.EmployeeId - b.EmployeeId
+	})
+	days, err := dayGrids(df, opts)
+	if err != nil {
+		return nil, err
+	}
+
+	files := map[string][]byte{}
+	formats := opts.formats()
+	if slices.Contains(formats, FormatJSON) {
+		data, err := json.MarshalIndent(ShiftExport{Version: ExportVersion, Shifts: shifts, Days: days}, "", "  ")
+		if err != nil {
+			return nil, fmt.Errorf("error encoding shifts: %v", err)
+		}
+		files["shifts.json"] = append(data, '\n')
+		files["shifts.schema.json"] = []byte(shiftsSchema)
+	}
+	if slices.Contains(formats, FormatCSV) {
+		rows := [][]string{{"employeeId", "firstName", "lastName", "shiftType", "department", "date", "weekNumber",
+			"time", "startTime", "endTime", "shiftLength", "hasLunch", "role", "phone"}}
+		for _, s := range shifts {
+			rows = append(rows, []string{strconv.Itoa(s.EmployeeId), s.FirstName, s.LastName, s.ShiftType, s.Department,
+				s.Date, strconv.Itoa(s.WeekNumber), s.Time, s.StartTime, s.EndTime,
+				strconv.FormatFloat(s.ShiftLength, 'f', -1, 64), strconv.FormatBool(s.HasLunch), s.Role, s.Phone})
+		}
+		if files["shifts.csv"], err = encodeCSV(rows); err != nil {
+			return nil, err
+		}
+
+		// One row per shift and slot
+		rows = [][]string{{"date", "slot", "employeeId", "name", "role", "state"}}
+		for _, day := range days {
+			for _, row := range day.Rows {
+				for i, state := range row.States {
+					rows = append(rows, []string{day.Date, day.Slots[i], strconv.Itoa(row.EmployeeId), row.Name, row.Role, state})
+				}
+			}
+		}
+		if files["grid.csv"], err = encodeCSV(rows); err != nil {
+			return nil, err
+		}
+	}
+	return files, nil
+}
+
+// dayGrids computes the hourly grid of every date like the day sheets do
+func dayGrids(df dataframe.DataFrame, opts ProcessOptions) ([]DayGrid, error) {
+	days := []DayGrid{}
+	for _, dateDf := range df.GroupBy("date").GetGroups() {
+		dateDf = dateDf.Arrange(dataframe.Sort("startTime"), dataframe.Sort("endTime"))
+		data, err := parseDayData(dateDf, opts)
+		if err != nil {
+			return nil, err
+		}
+		week, _ := strconv.Atoi(data.weekStr)
+		day := DayGrid{Date: data.dateStr, Weekday: data.dayStr, Week: week, Slots: data.headers[len(opts.headers()):]}
+		for _, shift := range data.shifts {
+			row := GridRow{EmployeeId: shift.employeeId, Name: shift.employeeName, Time: shift.shiftTime, Role: shift.role}
+			// The last state has no slot since the headers are compacted by one
+			for _, state := range shift.hourSchedule[:len(day.Slots)] {
+				row.States = append(row.States, state.String())
+			}
+			day.Rows = append(day.Rows, row)
+		}
+		days = append(days, day)
+	}
+	slices.SortFunc(days, func(a, b DayGrid) int { return strings.Compare(a.Date, b.Date) })
+	return days, nil
+}
+
+// encodeCSV writes comma separated rows with a BOM so Excel reads UTF-8
+func encodeCSV(rows [][]string) ([]byte, error) {
+	var buf bytes.Buffer
+	buf.WriteString("\xef\xbb\xbf")
+	w := csv.NewWriter(&buf)
+	if err := w.WriteAll(rows); err != nil {
+		return nil, fmt.Errorf("error writing csv: %v", err)
+	}
+	return buf.Bytes(), nil
+}
@@ -0,0 +1,56 @@
+package core
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+)
+
+func TestProcessFilesDataExports(t *testing.T) {
+	opts := DefaultProcessOptions()
+	opts.Formats = []string{"json", "csv"}
+	result, err := ProcessFilesWithOptions(buildInputFile(t, employeeShifts), nil, nil, opts)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	for _, name := range []string{"shifts.json", "shifts.schema.json", "shifts.csv", "grid.csv"} {
+		if len(result[name]) == 0 {
+			t.Fatalf("expected %s in result", name)
+		}
+	}
+	if _, ok := result["Vecka 12.xlsx"]; ok {
+		t.Fatalf("xlsx should not be rendered for data formats only")
+	}
+
+	var export ShiftExport
+	if err := json.Unmarshal(result["shifts.json"], &export); err != nil {
+		t.Fatalf("decoding shifts.json: %v", err)
+	}
+	if export.Version != ExportVersion || len(export.Shifts) != 3 || len(export.Days) != 3 {
+		t.Fatalf("unexpected export: %+v", export)
+	}
+	first := export.Shifts[0]
+	if first.EmployeeId != 1 || first.Date != "2025-03-17" || first.ShiftLength != 7 || !first.HasLunch {
+		t.Fatalf("unexpected first shift: %+v", first)
+	}
+	monday := export.Days[0]
+	if monday.Weekday != "Monday" || monday.Week != 12 || len(monday.Rows[0].States) != len(monday.Slots) {
+		t.Fatalf("unexpected day grid: %+v", monday)
+	}
+	if monday.Slots[4] != "14:00-15:00" || monday.Rows[0].States[4] != "lunch" {
+		t.Fatalf("expected lunch at 14:00, got %v %v", monday.Slots, monday.Rows[0].States)
+	}
+	var schema map[string]any
+	if err := json.Unmarshal(result["shifts.schema.json"], &schema); err != nil {
+		t.Fatalf("schema is not valid json: %v", err)
+	}
+
+	shiftsCSV := strings.TrimPrefix(string(result["shifts.csv"]), "\xef\xbb\xbf")
+	lines := strings.Split(strings.TrimSpace(shiftsCSV), "\n")
+	if len(lines) != 4 || !strings.HasPrefix(lines[1], "1,Anna,Svensson,Pass,Kassa,2025-03-17,12,09:00 - 17:00,09:00:00,17:00:00,7,true") {
+		t.Fatalf("unexpected shifts.csv:\n%s", shiftsCSV)
+	}
+	if !strings.Contains(string(result["grid.csv"]), "2025-03-17,14:00-15:00,1,Anna Svensson,,lunch") {
+		t.Fatalf("unexpected grid.csv:\n%s", result["grid.csv"])
+	}
+}
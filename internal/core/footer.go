@@ -0,0 +1,454 @@
+package core
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/xuri/excelize/v2"
+)
+
+// FooterCell is one cell copied from the footer sheet, Row and Col are
+// relative to the footer sheet
+type FooterCell struct {
+	Row       int
+	Col       int
+	Value     interface{}
+	Style     *excelize.Style
+	Merge     string // optional: e.g., "C20:D20"
+	Formula   string // optional, without leading "="
+	Hyperlink string // optional link target
+	LinkType  string // "External" or "Location"
+	RichText  []excelize.RichTextRun
+}
+
+// FooterPicture is a picture anchored in the footer sheet
+type FooterPicture struct {
+	Row     int
+	Col     int
+	Picture excelize.Picture
+}
+
+// Footer is the prepared content of a footer sheet
+type Footer struct {
+	Cells      []FooterCell
+	RowHeights map[int]float64 // custom heights by footer row
+	ColWidths  map[int]float64 // custom widths by column
+	Pictures   []FooterPicture
+}
+
+// Empty reports whether the footer has nothing to copy
+func (f Footer) Empty() bool {
+	return len(f.Cells) == 0 && len(f.Pictures) == 0
+}
+
+// Height is the number of rows used, including merged ranges and pictures
+func (f Footer) Height() int {
+	height := 0
+	for _, cell := range f.Cells {
+		height = max(height, cell.Row)
+		if bounds := strings.Split(cell.Merge, ":"); len(bounds) == 2 {
+			if _, row, err := excelize.CellNameToCoordinates(bounds[1]); err == nil {
+				height = max(height, row)
+			}
+		}
+	}
+	for _, p := range f.Pictures {
+		height = max(height, p.Row)
+	}
+	return height
+}
+
+// Lines returns the text of the block row by row with placeholders
+// replaced, for output formats without cells
+func (f Footer) Lines(values map[string]string) []string {
+	rows := make([][]FooterCell, f.Height())
+	for _, cell := range f.Cells {
+		if cell.Row > 0 {
+			rows[cell.Row-1] = append(rows[cell.Row-1], cell)
+		}
+	}
+	lines := make([]string, len(rows))
+	for i, cells := range rows {
+		slices.SortFunc(cells, func(a, b FooterCell) int { return a.Col - b.Col })
+		texts := []string{}
+		for _, cell := range cells {
+			text := ""
+			if cell.RichText != nil {
+				for _, run := range cell.RichText {
+					text += run.Text
+				}
+			} else if cell.Value != nil {
+				text = fmt.Sprint(cell.Value)
+			}
+			if text = strings.TrimSpace(replacePlaceholders(text, values)); text != "" {
+				texts = append(texts, text)
+			}
+		}
+		lines[i] = strings.Join(texts, "  ")
+	}
+	for len(lines) > 0 && lines[len(lines)-1] == "" {
+		lines = lines[:len(lines)-1]
+	}
+	return lines
+}
+
+/*
+================================================================================
+Handle footer from file
+================================================================================
+*/
+
+// PrepareFooter extracts data+styles once and returns a reusable footer
+func PrepareFooter(r io.Reader, sheet string) (Footer, error) {
+	if r == nil {
+		return Footer{}, errors.New("no footer file given")
+	}
+	srcFile, err := excelize.OpenReader(r)
+	if err != nil {
+		return Footer{}, errors.New("Error opening footer file: " + err.Error())
+	}
+	defer srcFile.Close()
+
+	return prepareFooterSheet(srcFile, sheet)
+}
+
+// blockSet holds the footer and header sheets of the footer file, including
+// variants such as "Footer-Saturday", keyed by lower case sheet name
+type blockSet map[string]Footer
+
+// prepareBlocks reads the footer and header sheets, with their variants, from
+// the footer file
+func prepareBlocks(r io.Reader, opts ProcessOptions) (blockSet, error) {
+	blocks := blockSet{}
+	if r == nil {
+		return blocks, errors.New("no footer file given")
+	}
+	srcFile, err := excelize.OpenReader(r)
+	if err != nil {
+		return blocks, errors.New("Error opening footer file: " + err.Error())
+	}
+	defer srcFile.Close()
+
+	hasFooter := false
+	for _, sheet := range srcFile.GetSheetList() {
+		isFooter := isBlockSheet(sheet, opts.FooterSheet)
+		if !isFooter && !isBlockSheet(sheet, opts.HeaderSheet) {
+			continue
+		}
+		block, err := prepareFooterSheet(srcFile, sheet)
+		if err != nil {
+			return blocks, fmt.Errorf("Error reading sheet %s: %v", sheet, err)
+		}
+		blocks[strings.ToLower(sheet)] = block
+		hasFooter = hasFooter || isFooter
+	}
+	if !hasFooter {
+		return blocks, fmt.Errorf("footer file has no sheet named %s or %s-<variant>, found: %s",
+			opts.FooterSheet, opts.FooterSheet, strings.Join(srcFile.GetSheetList(), ", "))
+	}
+	return blocks, nil
+}
+
+// isBlockSheet matches "Footer" and variants like "Footer-Saturday"
+func isBlockSheet(sheet string, base string) bool {
+	if base == "" {
+		return false
+	}
+	sheet, base = strings.ToLower(sheet), strings.ToLower(base)
+	return sheet == base || strings.HasPrefix(sheet, base+"-")
+}
+
+// resolve picks the most specific variant of a block for a day sheet:
+// date, weekday+department, weekday, department and finally the plain sheet.
+// department is empty when the day covers several departments.
+func (b blockSet) resolve(base string, date string, weekday string, department string) Footer {
+	candidates := []string{base + "-" + date}
+	if department != "" {
+		candidates = append(candidates, base+"-"+weekday+"-Dept-"+department)
+	}
+	candidates = append(candidates, base+"-"+weekday)
+	if department != "" {
+		candidates = append(candidates, base+"-Dept-"+department)
+	}
+	candidates = append(candidates, base)
+	for _, name := range candidates {
+		if block, ok := b[strings.ToLower(name)]; ok {
+			return block
+		}
+	}
+	return Footer{}
+}
+
+func prepareFooterSheet(srcFile *excelize.File, sheet string) (Footer, error) {
+	footer := Footer{RowHeights: map[int]float64{}, ColWidths: map[int]float64{}}
+
+	rows, err := srcFile.GetRows(sheet)
+	if err != nil {
+		return footer, err
+	}
+
+	// Used range also covers styled cells without a value
+	lastCol, lastRow := 0, len(rows)
+	for _, row := range rows {
+		lastCol = max(lastCol, len(row))
+	}
+	if dim, err := srcFile.GetSheetDimension(sheet); err == nil {
+		if parts := strings.Split(dim, ":"); len(parts) == 2 {
+			if col, row, err := excelize.CellNameToCoordinates(parts[1]); err == nil {
+				lastCol, lastRow = max(lastCol, col), max(lastRow, row)
+			}
+		}
+	}
+
+	merges := map[string]string{}
+	mergeCells, _ := srcFile.GetMergeCells(sheet)
+	for _, m := range mergeCells {
+		merges[m.GetStartAxis()] = m.GetStartAxis() + ":" + m.GetEndAxis()
+	}
+
+	for rowNum := 1; rowNum <= lastRow; rowNum++ {
+		for colNum := 1; colNum <= lastCol; colNum++ {
+			cellRef, _ := excelize.CoordinatesToCellName(colNum, rowNum)
+
+			var val interface{}
+			if rowNum <= len(rows) && colNum <= len(rows[rowNum-1]) {
+				val = rows[rowNum-1][colNum-1]
+			}
+			styleID, _ := srcFile.GetCellStyle(sheet, cellRef)
+			formula, _ := srcFile.GetCellFormula(sheet, cellRef)
+			hasLink, link, _ := srcFile.GetCellHyperLink(sheet, cellRef)
+			merge := merges[cellRef]
+			if (val == nil || val == "") && styleID == 0 && formula == "" && !hasLink && merge == "" {
+				continue
+			}
+
+			cell := FooterCell{
+				Row:     rowNum,
+				Col:     colNum,
+				Value:   val,
+				Merge:   merge,
+				Formula: formula,
+			}
+			if styleID != 0 {
+				cell.Style, _ = srcFile.GetStyle(styleID) // get full style definition
+			}
+			if hasLink {
+				cell.Hyperlink = link
+				cell.LinkType = "Location"
+				if strings.Contains(link, "://") || strings.HasPrefix(link, "mailto:") {
+					cell.LinkType = "External"
+				}
+			}
+			if runs, err := srcFile.GetCellRichText(sheet, cellRef); err == nil && isRichText(runs) {
+				cell.RichText = runs
+			}
+			footer.Cells = append(footer.Cells, cell)
+		}
+	}
+
+	// Row heights and column widths that differ from the defaults
+	for rowNum := 1; rowNum <= lastRow; rowNum++ {
+		if height, err := srcFile.GetRowHeight(sheet, rowNum); err == nil && height != defaultRowHeight {
+			footer.RowHeights[rowNum] = height
+		}
+	}
+	for colNum := 1; colNum <= lastCol; colNum++ {
+		colName, _ := excelize.ColumnNumberToName(colNum)
+		if width, err := srcFile.GetColWidth(sheet, colName); err == nil && width != defaultColWidth {
+			footer.ColWidths[colNum] = width
+		}
+	}
+
+	// Pictures, e.g. a store logo
+	cells, err := srcFile.GetPictureCells(sheet)
+	if err != nil {
+		return footer, err
+	}
+	for _, cellRef := range cells {
+		col, row, _ := excelize.CellNameToCoordinates(cellRef)
+		pics, _ := srcFile.GetPictures(sheet, cellRef)
+		for _, pic := range pics {
+			footer.Pictures = append(footer.Pictures, FooterPicture{Row: row, Col: col, Picture: pic})
+		}
+	}
+
+	return footer, nil
+}
+
+// Default sizes reported by excelize for rows and columns without custom size
+const (
+	defaultRowHeight = 15
+	defaultColWidth  = 9.140625
+)
+
+// A single run without formatting is plain text
+func isRichText(runs []excelize.RichTextRun) bool {
+	if len(runs) > 1 {
+		return true
+	}
+	return len(runs) == 1 && runs[0].Font != nil
+}
+
+// ApplyFooterToSheet inserts the prepared footer into a new file, placeholders
+// like {{date}} in text cells are replaced from values
+func ApplyFooterToSheet(dstFile *excelize.File, dstSheet string, footer Footer, rowOffset int, values map[string]string) error {
+	for _, cell := range footer.Cells {
+		cellName, _ := excelize.CoordinatesToCellName(cell.Col, cell.Row+rowOffset)
+		value := cell.Value
+		if text, ok := value.(string); ok {
+			value = replacePlaceholders(text, values)
+		}
+		if value != nil {
+			if err := dstFile.SetCellValue(dstSheet, cellName, value); err != nil {
+				return err
+			}
+		}
+		if cell.RichText != nil {
+			runs := make([]excelize.RichTextRun, len(cell.RichText))
+			for i, run := range cell.RichText {
+				runs[i] = run
+				runs[i].Text = replacePlaceholders(run.Text, values)
+			}
+			if err := dstFile.SetCellRichText(dstSheet, cellName, runs); err != nil {
+				return err
+			}
+		}
+		if cell.Formula != "" {
+			if err := dstFile.SetCellFormula(dstSheet, cellName, shiftFormulaRows(cell.Formula, rowOffset)); err != nil {
+				return err
+			}
+		}
+		if cell.Hyperlink != "" {
+			target := cell.Hyperlink
+			if cell.LinkType == "Location" {
+				target = shiftFormulaRows(target, rowOffset)
+			}
+			if err := dstFile.SetCellHyperLink(dstSheet, cellName, target, cell.LinkType); err != nil {
+				return err
+			}
+		}
+
+		if cell.Style != nil {
+			styleID, err := dstFile.NewStyle(cell.Style)
+			if err != nil {
+				return err
+			}
+			if err := dstFile.SetCellStyle(dstSheet, cellName, cellName, styleID); err != nil {
+				return err
+			}
+		}
+
+		if cell.Merge != "" {
+			bounds := strings.Split(shiftFormulaRows(cell.Merge, rowOffset), ":")
+			if len(bounds) == 2 {
+				if err := dstFile.MergeCell(dstSheet, bounds[0], bounds[1]); err != nil {
+					return err
+				}
+			}
+		}
+	}
+
+	for row, height := range footer.RowHeights {
+		if err := dstFile.SetRowHeight(dstSheet, row+rowOffset, height); err != nil {
+			return err
+		}
+	}
+	// Widen columns for the footer, never narrow the schedule
+	for col, width := range footer.ColWidths {
+		colName, _ := excelize.ColumnNumberToName(col)
+		current, err := dstFile.GetColWidth(dstSheet, colName)
+		if err != nil {
+			return err
+		}
+		if width > current {
+			if err := dstFile.SetColWidth(dstSheet, colName, colName, width); err != nil {
+				return err
+			}
+		}
+	}
+
+	for _, p := range footer.Pictures {
+		cellName, _ := excelize.CoordinatesToCellName(p.Col, p.Row+rowOffset)
+		pic := p.Picture
+		if err := dstFile.AddPictureFromBytes(dstSheet, cellName, &pic); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// Cell references like A1, $B$2 or Sheet!C3:D4, not followed by "(" (functions)
+var cellRefPattern = regexp.MustCompile(`\$?[A-Za-z]{1,3}\$?[0-9]+`)
+
+// shiftFormulaRows moves relative row references by offset, absolute
+// rows ($1) and text inside quotes are left untouched
+func shiftFormulaRows(formula string, offset int) string {
+	if offset == 0 {
+		return formula
+	}
+	var b strings.Builder
+	inString := false
+	start := 0
+	flush := func(end int) {
+		b.WriteString(shiftRefs(formula[start:end], offset))
+		start = end
+	}
+	for i := 0; i < len(formula); i++ {
+		if formula[i] != '"' {
+			continue
+		}
+		if !inString {
+			flush(i)
+		} else {
+			b.WriteString(formula[start : i+1])
+			start = i + 1
+		}
+		inString = !inString
+	}
+	if inString {
+		b.WriteString(formula[start:])
+	} else {
+		flush(len(formula))
+	}
+	return b.String()
+}
+
+func shiftRefs(s string, offset int) string {
+	matches := cellRefPattern.FindAllStringIndex(s, -1)
+	var b strings.Builder
+	last := 0
+	for _, m := range matches {
+		start, end := m[0], m[1]
+		// Must not be part of a longer name or a function call
+		if start > 0 && isNameChar(s[start-1]) {
+			continue
+		}
+		if end < len(s) && (isNameChar(s[end]) || s[end] == '(') {
+			continue
+		}
+		ref := s[start:end]
+		digits := strings.IndexAny(ref, "0123456789")
+		if ref[digits-1] == '$' {
+			continue // absolute row
+		}
+		row, err := strconv.Atoi(ref[digits:])
+		if err != nil {
+			continue
+		}
+		b.WriteString(s[last:start])
+		b.WriteString(fmt.Sprintf("%s%d", ref[:digits], row+offset))
+		last = end
+	}
+	b.WriteString(s[last:])
+	return b.String()
+}
+
+func isNameChar(c byte) bool {
+	return c == '_' || c == '.' || (c >= '0' && c <= '9') || (c >= 'a' && c <= 'z') || (c >= 'A' && c <= 'Z')
+}
@@ -0,0 +1,136 @@
+package core
+
+import (
+	"bytes"
+	"image"
+	"image/png"
+	"strings"
+	"testing"
+
+	"github.com/xuri/excelize/v2"
+)
+
+func TestShiftFormulaRows(t *testing.T) {
+	cases := []struct {
+		formula string
+		offset  int
+		want    string
+	}{
+		{"SUM(A1:B2)", 10, "SUM(A11:B12)"},
+		{"A$1+$B2", 5, "A$1+$B7"},
+		{`IF(A1="B2",LOG10(C3),0)`, 1, `IF(A2="B2",LOG10(C4),0)`},
+		{"Sheet1!C3*2", 2, "Sheet1!C5*2"},
+		{"A1", 0, "A1"},
+	}
+	for _, c := range cases {
+		if got := shiftFormulaRows(c.formula, c.offset); got != c.want {
+			t.Fatalf("shiftFormulaRows(%q, %d) = %q, want %q", c.formula, c.offset, got, c.want)
+		}
+	}
+}
+
+func TestFooterFullFidelity(t *testing.T) {
+	src := excelize.NewFile()
+	src.NewSheet("Footer")
+	src.SetCellValue("Footer", "A1", "Closing manager")
+	src.MergeCell("Footer", "A1", "C1")
+	src.SetRowHeight("Footer", 1, 30)
+	src.SetColWidth("Footer", "E", "E", 50)
+	src.SetCellValue("Footer", "A2", 2)
+	src.SetCellValue("Footer", "B2", 3)
+	src.SetCellFormula("Footer", "C2", "A2*B2")
+	src.SetCellValue("Footer", "D2", "Intranet")
+	src.SetCellHyperLink("Footer", "D2", "https://example.com", "External")
+	src.SetCellRichText("Footer", "E2", []excelize.RichTextRun{
+		{Text: "Bold ", Font: &excelize.Font{Bold: true}},
+		{Text: "normal"},
+	})
+	var logo bytes.Buffer
+	png.Encode(&logo, image.NewRGBA(image.Rect(0, 0, 4, 4)))
+	src.AddPictureFromBytes("Footer", "F1", &excelize.Picture{Extension: ".png", File: logo.Bytes()})
+	buf, err := src.WriteToBuffer()
+	if err != nil {
+		t.Fatalf("failed writing footer buffer: %v", err)
+	}
+
+	footer, err := PrepareFooter(bytes.NewReader(buf.Bytes()), "Footer")
+	if err != nil {
+		t.Fatalf("PrepareFooter error: %v", err)
+	}
+
+	dst := excelize.NewFile()
+	if err := ApplyFooterToSheet(dst, "Sheet1", footer, 10, nil); err != nil {
+		t.Fatalf("ApplyFooterToSheet error: %v", err)
+	}
+
+	merged, _ := dst.GetMergeCells("Sheet1")
+	if len(merged) != 1 || merged[0].GetStartAxis() != "A11" || merged[0].GetEndAxis() != "C11" {
+		t.Fatalf("expected merge A11:C11, got %v", merged)
+	}
+	if height, _ := dst.GetRowHeight("Sheet1", 11); height != 30 {
+		t.Fatalf("expected row height 30, got %v", height)
+	}
+	if width, _ := dst.GetColWidth("Sheet1", "E"); width != 50 {
+		t.Fatalf("expected column width 50, got %v", width)
+	}
+	if formula, _ := dst.GetCellFormula("Sheet1", "C12"); formula != "A12*B12" {
+		t.Fatalf("expected shifted formula, got %q", formula)
+	}
+	if ok, link, _ := dst.GetCellHyperLink("Sheet1", "D12"); !ok || link != "https://example.com" {
+		t.Fatalf("expected hyperlink, got %v %q", ok, link)
+	}
+	if runs, _ := dst.GetCellRichText("Sheet1", "E12"); len(runs) != 2 || runs[0].Font == nil || !runs[0].Font.Bold {
+		t.Fatalf("expected rich text, got %+v", runs)
+	}
+	if cells, _ := dst.GetPictureCells("Sheet1"); len(cells) != 1 || cells[0] != "F11" {
+		t.Fatalf("expected logo in F11, got %v", cells)
+	}
+}
+
+func TestPrepareBlocksResolveVariants(t *testing.T) {
+	src := excelize.NewFile()
+	for _, sheet := range []string{"Footer", "Footer-Saturday", "footer-dept-Bakery", "Footer-Saturday-Dept-Bakery", "Footer-2025-12-24", "Header", "Notes"} {
+		src.NewSheet(sheet)
+		src.SetCellValue(sheet, "A1", sheet)
+	}
+	buf, err := src.WriteToBuffer()
+	if err != nil {
+		t.Fatalf("failed writing footer buffer: %v", err)
+	}
+
+	blocks, err := prepareBlocks(bytes.NewReader(buf.Bytes()), DefaultProcessOptions())
+	if err != nil {
+		t.Fatalf("prepareBlocks error: %v", err)
+	}
+	if _, ok := blocks["notes"]; ok {
+		t.Fatalf("unrelated sheet should not be loaded")
+	}
+
+	cases := []struct {
+		date, weekday, department, want string
+	}{
+		{"2025-12-24", "Wednesday", "Bakery", "Footer-2025-12-24"},
+		{"2025-12-20", "Saturday", "Bakery", "Footer-Saturday-Dept-Bakery"},
+		{"2025-12-20", "Saturday", "", "Footer-Saturday"},
+		{"2025-12-19", "Friday", "bakery", "footer-dept-Bakery"},
+		{"2025-12-19", "Friday", "Deli", "Footer"},
+	}
+	for _, c := range cases {
+		footer := blocks.resolve("Footer", c.date, c.weekday, c.department)
+		if len(footer.Cells) == 0 || footer.Cells[0].Value != c.want {
+			t.Fatalf("resolve(%s, %s, %s) = %+v, want %s", c.date, c.weekday, c.department, footer.Cells, c.want)
+		}
+	}
+	if header := blocks.resolve("Header", "2025-12-20", "Saturday", ""); header.Empty() {
+		t.Fatalf("expected header block to resolve")
+	}
+}
+
+func TestPrepareBlocksMissingFooterSheet(t *testing.T) {
+	src := excelize.NewFile()
+	buf, _ := src.WriteToBuffer()
+	_, err := prepareBlocks(bytes.NewReader(buf.Bytes()), DefaultProcessOptions())
+	if err == nil || !strings.Contains(err.Error(), "Sheet1") {
+		t.Fatalf("expected error listing found sheets, got %v", err)
+	}
+}
@@ -0,0 +1,437 @@
+package core
+
+import (
+	"cmp"
+	"errors"
+	"fmt"
+	"io"
+	"math/rand"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/xuri/excelize/v2"
+)
+
+// GeneratorOptions holds the working-time rules of the schedule generator
+type GeneratorOptions struct {
+	// Same seed and input give the same schedule
+	Seed int64 `json:"seed" toml:"seed" yaml:"seed"`
+	// Shift length in whole hours, lunch included
+	MinShiftHours int `json:"minShiftHours" toml:"minShiftHours" yaml:"minShiftHours"`
+	MaxShiftHours int `json:"maxShiftHours" toml:"maxShiftHours" yaml:"maxShiftHours"`
+	// Rest between the end of a shift and the start of the next
+	DailyRestHours float64 `json:"dailyRestHours" toml:"dailyRestHours" yaml:"dailyRestHours"`
+	// Working days per ISO week
+	MaxDaysPerWeek int `json:"maxDaysPerWeek" toml:"maxDaysPerWeek" yaml:"maxDaysPerWeek"`
+	// Shift type written to the generated export
+	ShiftType string `json:"shiftType" toml:"shiftType" yaml:"shiftType"`
+}
+
+func defaultGeneratorOptions() GeneratorOptions {
+	return GeneratorOptions{
+		Seed:           1,
+		MinShiftHours:  3,
+		MaxShiftHours:  10,
+		DailyRestHours: 11,
+		MaxDaysPerWeek: 5,
+		ShiftType:      "Pass",
+	}
+}
+
+func (g GeneratorOptions) validate() error {
+	if g.MinShiftHours < 1 || g.MaxShiftHours < g.MinShiftHours || g.MaxShiftHours > 24 {
+		return fmt.Errorf("shift hours must satisfy 1 <= minShiftHours <= maxShiftHours <= 24")
+	}
+	if g.DailyRestHours < 0 || g.MaxDaysPerWeek < 1 || g.MaxDaysPerWeek > 7 {
+		return fmt.Errorf("dailyRestHours must not be negative and maxDaysPerWeek must be 1-7")
+	}
+	return nil
+}
+
+// Availability preferences, higher is chosen first
+const (
+	PreferenceAvoid     = -1
+	PreferenceAvailable = 0
+	PreferencePreferred = 1
+)
+
+// availability is one row of the availability file
+type availability struct {
+	day        string // lower case weekday or YYYY-MM-DD
+	from, to   int    // minutes of the day
+	preference int
+	blocked    bool // unavailable
+}
+
+// candidate is an employee the generator can schedule
+type candidate struct {
+	id            int
+	lastName      string
+	firstName     string
+	department    string
+	contractHours float64 // per ISO week, 0 is unlimited
+	windows       []availability
+	rank          int // seeded tie-break
+
+	shifts      []generatedShift
+	weekMinutes map[string]int
+	weekDays    map[string]int
+}
+
+// generatedShift is a proposed shift on whole hours
+type generatedShift struct {
+	employee *candidate
+	date     time.Time
+	start    int // hour of the day
+	end      int // hour of the day, before midnight
+}
+
+// GenerationReport lists what the generator could not cover
+type GenerationReport struct {
+	Shifts   int      `json:"shifts"`
+	Unfilled []string `json:"unfilled"`
+}
+
+// Swedish weekday names accepted in the availability file
+var swedishWeekdays = map[string]string{
+	"måndag": "monday", "tisdag": "tuesday", "onsdag": "wednesday", "torsdag": "thursday",
+	"fredag": "friday", "lördag": "saturday", "söndag": "sunday",
+}
+
+/*
+================================================================================
+Read the availability file: employeeId, lastName, firstName, department,
+contractHours, day (weekday or date), from, to and preference
+================================================================================
+*/
+func readAvailability(r io.Reader) ([]*candidate, error) {
+	rows, err := readTable(r)
+	if err != nil {
+		return nil, errors.New("Error reading availability: " + err.Error())
+	}
+	byId := map[int]*candidate{}
+	for i, record := range tableRecords(rows) {
+		fail := func(format string, a ...any) error {
+			return fmt.Errorf("Error reading availability row %d: %s", i+2, fmt.Sprintf(format, a...))
+		}
+		id, err := strconv.Atoi(record["employeeid"])
+		if err != nil {
+			return nil, fail("invalid employeeId %q", record["employeeid"])
+		}
+		c, ok := byId[id]
+		if !ok {
+			c = &candidate{id: id, weekMinutes: map[string]int{}, weekDays: map[string]int{}}
+			byId[id] = c
+		}
+		// Employee details may be given on any of the rows
+		c.lastName = cmp.Or(record["lastname"], c.lastName)
+		c.firstName = cmp.Or(record["firstname"], c.firstName)
+		c.department = cmp.Or(record["department"], c.department)
+		if hours := record["contracthours"]; hours != "" {
+			if c.contractHours, err = strconv.ParseFloat(strings.ReplaceAll(hours, ",", "."), 64); err != nil {
+				return nil, fail("invalid contractHours %q", hours)
+			}
+		}
+
+		day := strings.ToLower(strings.TrimSpace(record["day"]))
+		if day == "" {
+			continue // employee details only
+		}
+		if english, ok := swedishWeekdays[day]; ok {
+			day = english
+		}
+		if !slices.Contains([]string{"monday", "tuesday", "wednesday", "thursday", "friday", "saturday", "sunday"}, day) {
+			if day, err = normalizeDate(day); err != nil {
+				return nil, fail("%v", err)
+			}
+		}
+		window := availability{day: day, from: 0, to: 24 * 60}
+		if record["from"] != "" {
+			if window.from, err = minuteOfDay(record["from"]); err != nil {
+				return nil, fail("%v", err)
+			}
+		}
+		if record["to"] != "" {
+			if window.to, err = minuteOfDay(record["to"]); err != nil {
+				return nil, fail("%v", err)
+			}
+		}
+		switch strings.ToLower(record["preference"]) {
+		case "", "available", "tillgänglig":
+		case "preferred", "önskad", "prefer":
+			window.preference = PreferencePreferred
+		case "avoid", "undvik":
+			window.preference = PreferenceAvoid
+		case "unavailable", "ledig", "no":
+			window.blocked = true
+		default:
+			return nil, fail("invalid preference %q", record["preference"])
+		}
+		c.windows = append(c.windows, window)
+	}
+
+	candidates := []*candidate{}
+	for _, c := range byId {
+		candidates = append(candidates, c)
+	}
+	slices.SortFunc(candidates, func(a, b *candidate) int { return a.id - b.id })
+	return candidates, nil
+}
+
+// hourPreference returns the preference of working an hour on a date, false when not available
+func (c *candidate) hourPreference(date time.Time, hour int) (int, bool) {
+	dateStr, weekday := date.Format(time.DateOnly), strings.ToLower(date.Weekday().String())
+	from, to := hour*60, hour*60+60
+	preference, available := PreferenceAvoid-1, false
+	for _, w := range c.windows {
+		if w.day != dateStr && w.day != weekday {
+			continue
+		}
+		if w.blocked && w.from < to && from < w.to {
+			return 0, false
+		}
+		if !w.blocked && w.from <= from && to <= w.to {
+			available = true
+			preference = max(preference, w.preference)
+		}
+	}
+	return preference, available
+}
+
+// isoWeek keys the weekly limits
+func isoWeek(date time.Time) string {
+	year, week := date.ISOWeek()
+	return fmt.Sprintf("%d-%02d", year, week)
+}
+
+// paidMinutes deducts lunch like the input refinement does
+func paidMinutes(hours int, opts ProcessOptions) int {
+	if float64(hours) > opts.LunchMinShiftHours {
+		return int((float64(hours) - opts.LunchHours) * 60)
+	}
+	return hours * 60
+}
+
+// lunchHours returns the hours of a shift overlapped by its lunch
+func lunchHours(start int, end int, opts ProcessOptions) []int {
+	if float64(end-start) <= opts.LunchMinShiftHours || opts.LunchHours <= 0 {
+		return nil
+	}
+	lunchStart := float64(start) + opts.LunchAfterHours
+	lunchEnd := lunchStart + opts.LunchHours
+	hours := []int{}
+	for h := start; h < end; h++ {
+		if float64(h) < lunchEnd && lunchStart < float64(h+1) {
+			hours = append(hours, h)
+		}
+	}
+	return hours
+}
+
+// fits checks the working-time rules for a new shift
+func (c *candidate) fits(shift generatedShift, opts ProcessOptions) bool {
+	rules := opts.Generator
+	week := isoWeek(shift.date)
+	if c.weekDays[week] >= rules.MaxDaysPerWeek {
+		return false
+	}
+	if c.contractHours > 0 && float64(c.weekMinutes[week]+paidMinutes(shift.end-shift.start, opts)) > c.contractHours*60 {
+		return false
+	}
+	start := shift.date.Add(time.Duration(shift.start) * time.Hour)
+	end := shift.date.Add(time.Duration(shift.end) * time.Hour)
+	rest := time.Duration(rules.DailyRestHours * float64(time.Hour))
+	for _, other := range c.shifts {
+		if other.date.Equal(shift.date) {
+			return false
+		}
+		otherStart := other.date.Add(time.Duration(other.start) * time.Hour)
+		otherEnd := other.date.Add(time.Duration(other.end) * time.Hour)
+		if start.Before(otherEnd.Add(rest)) && otherStart.Before(end.Add(rest)) {
+			return false
+		}
+	}
+	return true
+}
+
+/*
+================================================================================
+Propose shifts covering the demand: the slot missing the most staff is
+filled first with the best scoring shift of an available employee
+================================================================================
+*/
+func GenerateSchedule(demandReader io.Reader, availabilityReader io.Reader, opts ProcessOptions) ([]byte, GenerationReport, error) {
+	report := GenerationReport{}
+	if err := opts.Validate(); err != nil {
+		return nil, report, errors.New("Invalid options: " + err.Error())
+	}
+	if err := opts.Generator.validate(); err != nil {
+		return nil, report, errors.New("Invalid generator options: " + err.Error())
+	}
+	demand, err := readDemand(demandReader, opts.Staffing)
+	if err != nil {
+		return nil, report, err
+	}
+	candidates, err := readAvailability(availabilityReader)
+	if err != nil {
+		return nil, report, err
+	}
+	rng := rand.New(rand.NewSource(opts.Generator.Seed))
+	for i, rank := range rng.Perm(len(candidates)) {
+		candidates[i].rank = rank
+	}
+
+	// Unmet staff per date and hour
+	type slot struct {
+		date time.Time
+		hour int
+	}
+	unmet := map[slot]int{}
+	for dateStr, slots := range demand {
+		date, err := time.Parse(time.DateOnly, dateStr)
+		if err != nil {
+			return nil, report, fmt.Errorf("invalid demand date %q", dateStr)
+		}
+		for start, required := range slots {
+			if required > 0 {
+				unmet[slot{date, start / 60}] += int(required + 0.999)
+			}
+		}
+	}
+	unfillable := map[slot]bool{}
+
+	shifts := []generatedShift{}
+	for {
+		// Most unmet slot first, earliest on ties
+		var target slot
+		found := false
+		for s, n := range unmet {
+			if n <= 0 || unfillable[s] {
+				continue
+			}
+			if !found || n > unmet[target] || (n == unmet[target] &&
+				(s.date.Before(target.date) || (s.date.Equal(target.date) && s.hour < target.hour))) {
+				target, found = s, true
+			}
+		}
+		if !found {
+			break
+		}
+
+		var best generatedShift
+		bestScore := 0
+		for _, c := range candidates {
+			for length := opts.Generator.MinShiftHours; length <= opts.Generator.MaxShiftHours; length++ {
+				for start := target.hour - length + 1; start <= target.hour; start++ {
+					// The export has no way to show a shift ending at or past midnight
+					if start < 0 || start+length >= 24 {
+						continue
+					}
+					shift := generatedShift{employee: c, date: target.date, start: start, end: start + length}
+					lunch := lunchHours(shift.start, shift.end, opts)
+					if slices.Contains(lunch, target.hour) || !c.fits(shift, opts) {
+						continue
+					}
+					score, ok := 0, true
+					for h := shift.start; h < shift.end && ok; h++ {
+						preference, available := c.hourPreference(shift.date, h)
+						if !available {
+							ok = false
+							break
+						}
+						score += preference * 3
+						if slices.Contains(lunch, h) {
+							continue
+						}
+						if unmet[slot{shift.date, h}] > 0 {
+							score += 10
+						} else {
+							score -= 4
+						}
+					}
+					if !ok {
+						continue
+					}
+					// Shorter shifts come first and win ties of the same employee
+					if best.employee == nil || score > bestScore || (score == bestScore && c.rank < best.employee.rank) {
+						best, bestScore = shift, score
+					}
+				}
+			}
+		}
+		if best.employee == nil {
+			unfillable[target] = true
+			continue
+		}
+
+		c := best.employee
+		week := isoWeek(best.date)
+		c.shifts = append(c.shifts, best)
+		c.weekDays[week]++
+		c.weekMinutes[week] += paidMinutes(best.end-best.start, opts)
+		lunch := lunchHours(best.start, best.end, opts)
+		for h := best.start; h < best.end; h++ {
+			if slices.Contains(lunch, h) {
+				continue
+			}
+			s := slot{best.date, h}
+			if unmet[s] > 0 {
+				unmet[s]--
+			}
+		}
+		shifts = append(shifts, best)
+	}
+
+	for s, n := range unmet {
+		if n > 0 {
+			report.Unfilled = append(report.Unfilled, fmt.Sprintf("%s %02d:00-%02d:00: %d short",
+				s.date.Format(time.DateOnly), s.hour, (s.hour+1)%24, n))
+		}
+	}
+	slices.Sort(report.Unfilled)
+	report.Shifts = len(shifts)
+
+	buf, err := writeGeneratedExport(shifts, opts)
+	if err != nil {
+		return nil, report, err
+	}
+	return buf, report, nil
+}
+
+// writeGeneratedExport writes the shifts in the layout of the input export
+func writeGeneratedExport(shifts []generatedShift, opts ProcessOptions) ([]byte, error) {
+	slices.SortFunc(shifts, func(a, b generatedShift) int {
+		if c := a.date.Compare(b.date); c != 0 {
+			return c
+		}
+		if a.start != b.start {
+			return a.start - b.start
+		}
+		return a.employee.id - b.employee.id
+	})
+
+	f := excelize.NewFile()
+	defer f.Close()
+	if err := f.SetSheetName("Sheet1", opts.InputSheet); err != nil {
+		return nil, err
+	}
+	f.SetSheetRow(opts.InputSheet, "A1", &[]interface{}{"Genererat schema, seed " + strconv.FormatInt(opts.Generator.Seed, 10)})
+	f.SetSheetRow(opts.InputSheet, "A2", &[]interface{}{"Id", "Efternamn", "Förnamn", "Typ", "Datum", "Tid", "Avdelning"})
+	for i, shift := range shifts {
+		c := shift.employee
+		cell, _ := excelize.CoordinatesToCellName(1, i+3)
+		f.SetSheetRow(opts.InputSheet, cell, &[]interface{}{
+			strconv.Itoa(c.id), c.lastName, c.firstName, opts.Generator.ShiftType,
+			shift.date.Format(time.DateOnly),
+			fmt.Sprintf("%02d:00 - %02d:00", shift.start, shift.end),
+			c.department,
+		})
+	}
+	buf, err := f.WriteToBuffer()
+	if err != nil {
+		return nil, fmt.Errorf("error writing to buffer: %v", err)
+	}
+	return buf.Bytes(), nil
+}
@@ -0,0 +1,103 @@
+package core
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+	"testing"
+)
+
+const generatorDemand = `date,slot,required
+2025-03-17,10:00,1
+2025-03-17,11:00,1
+2025-03-17,12:00,2
+2025-03-17,13:00,2
+2025-03-17,14:00,1
+2025-03-17,15:00,1
+2025-03-17,16:00,1
+`
+
+const generatorAvailability = `employeeId;lastName;firstName;department;contractHours;day;from;to;preference
+1;Svensson;Anna;Kassa;40;monday;08:00;18:00;preferred
+2;Berg;Erik;Kassa;20;monday;10:00;16:00;
+3;Ek;Sara;Kassa;10;måndag;;;unavailable
+3;;;;;monday;09:00;17:00;
+`
+
+func TestGenerateSchedule(t *testing.T) {
+	opts := DefaultProcessOptions()
+	generate := func() []Shift {
+		buf, report, err := GenerateSchedule(strings.NewReader(generatorDemand), strings.NewReader(generatorAvailability), opts)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if len(report.Unfilled) != 0 {
+			t.Fatalf("expected all demand covered, got %v", report.Unfilled)
+		}
+		// The draft is read like any export
+		shifts, err := ReadShifts(bytes.NewReader(buf), nil, opts)
+		if err != nil {
+			t.Fatalf("reading generated export: %v", err)
+		}
+		if len(shifts) != report.Shifts {
+			t.Fatalf("expected %d shifts, read %d", report.Shifts, len(shifts))
+		}
+		return shifts
+	}
+	shifts := generate()
+	for _, s := range shifts {
+		if s.EmployeeId == 3 {
+			t.Fatalf("Sara is unavailable on Monday but got %s", s.Time)
+		}
+		if s.EmployeeId == 2 && (s.StartTime < "10:00:00" || s.EndTime > "16:00:00") {
+			t.Fatalf("Erik is scheduled outside his availability: %s", s.Time)
+		}
+		if s.Department != "Kassa" {
+			t.Fatalf("expected department from the availability file, got %q", s.Department)
+		}
+	}
+	if again := generate(); fmt.Sprint(again) != fmt.Sprint(shifts) {
+		t.Fatalf("expected the same schedule for the same seed")
+	}
+}
+
+func TestGenerateScheduleRest(t *testing.T) {
+	demand := "date,slot,required\n2025-03-17,21:00,1\n2025-03-18,06:00,1\n"
+	availability := "employeeId,lastName,firstName,day\n1,Svensson,Anna,monday\n1,,,tuesday\n"
+	opts := DefaultProcessOptions()
+	_, report, err := GenerateSchedule(strings.NewReader(demand), strings.NewReader(availability), opts)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	// 11 hours of rest do not fit between the evening and the morning
+	if len(report.Unfilled) != 1 || report.Shifts != 1 {
+		t.Fatalf("expected one shift and one unfilled slot, got %+v", report)
+	}
+
+	opts.Generator.DailyRestHours = 0
+	_, report, _ = GenerateSchedule(strings.NewReader(demand), strings.NewReader(availability), opts)
+	if len(report.Unfilled) != 0 || report.Shifts != 2 {
+		t.Fatalf("expected both slots filled without the rest rule, got %+v", report)
+	}
+}
+
+func TestGenerateScheduleLateDemand(t *testing.T) {
+	demand := "date,slot,required\n2025-03-17,22:00,1\n2025-03-17,23:00,1\n"
+	availability := "employeeId,lastName,firstName,department,day\n1,Svensson,Anna,Kassa,monday\n"
+	opts := DefaultProcessOptions()
+	buf, report, err := GenerateSchedule(strings.NewReader(demand), strings.NewReader(availability), opts)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	// Shifts end before midnight, the last hour cannot be covered
+	if report.Shifts != 1 || len(report.Unfilled) != 1 || !strings.HasPrefix(report.Unfilled[0], "2025-03-17 23:00") {
+		t.Fatalf("expected one shift and 23:00 unfilled, got %+v", report)
+	}
+	shifts, err := ReadShifts(bytes.NewReader(buf), nil, opts)
+	if err != nil || len(shifts) != 1 || shifts[0].ShiftLength <= 0 || shifts[0].EndTime != "23:00:00" {
+		t.Fatalf("unexpected generated shifts %+v (%v)", shifts, err)
+	}
+	if _, err := ProcessFilesWithOptions(bytes.NewReader(buf), nil, nil, opts); err != nil {
+		t.Fatalf("rendering the generated export: %v", err)
+	}
+}
@@ -0,0 +1,140 @@
+package core
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+)
+
+// Holiday is a public holiday or a holiday eve
+type Holiday struct {
+	Date   string `json:"date"` // YYYY-MM-DD
+	Name   string `json:"name"`
+	RedDay bool   `json:"redDay"` // false for eves such as Midsommarafton
+}
+
+// CustomHoliday adds a local holiday, e.g. a closing day
+type CustomHoliday struct {
+	Date   string `json:"date" toml:"date" yaml:"date"`
+	Name   string `json:"name" toml:"name" yaml:"name"`
+	RedDay bool   `json:"redDay" toml:"redDay" yaml:"redDay"`
+}
+
+// HolidayOptions selects the built-in holidays and adds custom ones
+type HolidayOptions struct {
+	// Built-in calendar: "SE" or "" for none, the default
+	Country string `json:"country" toml:"country" yaml:"country"`
+	// Include eves such as Julafton and Midsommarafton
+	Eves   bool            `json:"eves" toml:"eves" yaml:"eves"`
+	Custom []CustomHoliday `json:"custom" toml:"custom" yaml:"custom"`
+}
+
+func defaultHolidayOptions() HolidayOptions {
+	return HolidayOptions{Eves: true}
+}
+
+func (h HolidayOptions) validate() error {
+	if h.Country != "" && !strings.EqualFold(h.Country, "SE") {
+		return fmt.Errorf("unknown holiday country %q", h.Country)
+	}
+	for _, c := range h.Custom {
+		if _, err := time.Parse(time.DateOnly, c.Date); err != nil {
+			return fmt.Errorf("invalid custom holiday date %q", c.Date)
+		}
+	}
+	return nil
+}
+
+// HolidayCalendar looks up holidays by date
+type HolidayCalendar struct {
+	opts HolidayOptions
+}
+
+// NewHolidayCalendar returns a calendar for the options
+func NewHolidayCalendar(opts HolidayOptions) HolidayCalendar {
+	return HolidayCalendar{opts: opts}
+}
+
+// Lookup returns the holiday on a date (YYYY-MM-DD), custom ones win
+func (c HolidayCalendar) Lookup(date string) (Holiday, bool) {
+	for _, custom := range c.opts.Custom {
+		if custom.Date == date {
+			return Holiday(custom), true
+		}
+	}
+	t, err := time.Parse(time.DateOnly, date)
+	if err != nil || !strings.EqualFold(c.opts.Country, "SE") {
+		return Holiday{}, false
+	}
+	for _, h := range SwedishHolidays(t.Year()) {
+		if h.Date == date && (h.RedDay || c.opts.Eves) {
+			return h, true
+		}
+	}
+	return Holiday{}, false
+}
+
+/*
+================================================================================
+Swedish public holidays (röda dagar) and the eves treated as holidays
+================================================================================
+*/
+func SwedishHolidays(year int) []Holiday {
+	date := func(month time.Month, day int) time.Time {
+		return time.Date(year, month, day, 0, 0, 0, 0, time.UTC)
+	}
+	// First given weekday on or after a date
+	onOrAfter := func(t time.Time, weekday time.Weekday) time.Time {
+		return t.AddDate(0, 0, (int(weekday)-int(t.Weekday())+7)%7)
+	}
+	easter := easterSunday(year)
+	midsummer := onOrAfter(date(time.June, 20), time.Saturday)
+	allSaints := onOrAfter(date(time.October, 31), time.Saturday)
+
+	holidays := []struct {
+		date   time.Time
+		name   string
+		redDay bool
+	}{
+		{date(time.January, 1), "Nyårsdagen", true},
+		{date(time.January, 6), "Trettondedag jul", true},
+		{easter.AddDate(0, 0, -2), "Långfredagen", true},
+		{easter.AddDate(0, 0, -1), "Påskafton", false},
+		{easter, "Påskdagen", true},
+		{easter.AddDate(0, 0, 1), "Annandag påsk", true},
+		{date(time.May, 1), "Första maj", true},
+		{easter.AddDate(0, 0, 39), "Kristi himmelsfärdsdag", true},
+		{easter.AddDate(0, 0, 49), "Pingstdagen", true},
+		{date(time.June, 6), "Sveriges nationaldag", true},
+		{midsummer.AddDate(0, 0, -1), "Midsommarafton", false},
+		{midsummer, "Midsommardagen", true},
+		{allSaints, "Alla helgons dag", true},
+		{date(time.December, 24), "Julafton", false},
+		{date(time.December, 25), "Juldagen", true},
+		{date(time.December, 26), "Annandag jul", true},
+		{date(time.December, 31), "Nyårsafton", false},
+	}
+	result := make([]Holiday, len(holidays))
+	for i, h := range holidays {
+		result[i] = Holiday{Date: h.date.Format(time.DateOnly), Name: h.name, RedDay: h.redDay}
+	}
+	slices.SortStableFunc(result, func(a, b Holiday) int { return strings.Compare(a.Date, b.Date) })
+	return result
+}
+
+// easterSunday uses the anonymous Gregorian algorithm
+func easterSunday(year int) time.Time {
+	a := year % 19
+	b, c := year/100, year%100
+	d, e := b/4, b%4
+	f := (b + 8) / 25
+	g := (b - f + 1) / 3
+	h := (19*a + b - d - g + 15) % 30
+	i, k := c/4, c%4
+	l := (32 + 2*e + 2*i - h - k) % 7
+	m := (a + 11*h + 22*l) / 451
+	month := (h + l - 7*m + 114) / 31
+	day := (h+l-7*m+114)%31 + 1
+	return time.Date(year, time.Month(month), day, 0, 0, 0, 0, time.UTC)
+}
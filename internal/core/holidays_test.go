@@ -0,0 +1,102 @@
+package core
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/xuri/excelize/v2"
+)
+
+func TestSwedishHolidays(t *testing.T) {
+	holidays := map[string]Holiday{}
+	for _, h := range SwedishHolidays(2025) {
+		holidays[h.Date] = h
+	}
+	expect := map[string]string{
+		"2025-01-01": "Nyårsdagen",
+		"2025-04-18": "Långfredagen",
+		"2025-04-20": "Påskdagen",
+		"2025-04-21": "Annandag påsk",
+		"2025-05-29": "Kristi himmelsfärdsdag",
+		"2025-06-08": "Pingstdagen",
+		"2025-06-20": "Midsommarafton",
+		"2025-06-21": "Midsommardagen",
+		"2025-11-01": "Alla helgons dag",
+		"2025-12-24": "Julafton",
+	}
+	for date, name := range expect {
+		if holidays[date].Name != name {
+			t.Fatalf("expected %s on %s, got %q", name, date, holidays[date].Name)
+		}
+	}
+	if holidays["2025-06-20"].RedDay || !holidays["2025-06-21"].RedDay {
+		t.Fatalf("expected Midsommarafton to be an eve and Midsommardagen a red day")
+	}
+}
+
+func TestHolidayCalendarLookup(t *testing.T) {
+	if _, ok := NewHolidayCalendar(defaultHolidayOptions()).Lookup("2025-12-25"); ok {
+		t.Fatalf("expected no built-in holidays by default")
+	}
+	opts := defaultHolidayOptions()
+	opts.Country = "SE"
+	opts.Custom = []CustomHoliday{{Date: "2025-03-18", Name: "Inventering"}, {Date: "2025-12-24", Name: "Stängt", RedDay: true}}
+	calendar := NewHolidayCalendar(opts)
+
+	if h, ok := calendar.Lookup("2025-03-18"); !ok || h.Name != "Inventering" {
+		t.Fatalf("expected custom holiday, got %+v", h)
+	}
+	if h, _ := calendar.Lookup("2025-12-24"); h.Name != "Stängt" || !h.RedDay {
+		t.Fatalf("expected custom holiday to win, got %+v", h)
+	}
+	if _, ok := calendar.Lookup("2025-03-17"); ok {
+		t.Fatalf("expected no holiday on an ordinary day")
+	}
+
+	opts.Eves = false
+	if _, ok := NewHolidayCalendar(opts).Lookup("2025-06-20"); ok {
+		t.Fatalf("expected eves to be left out")
+	}
+	opts.Country = ""
+	if _, ok := NewHolidayCalendar(opts).Lookup("2025-06-21"); ok {
+		t.Fatalf("expected no built-in holidays without a country")
+	}
+
+	opts.Country = "NO"
+	if err := opts.validate(); err == nil {
+		t.Fatalf("expected unknown country to fail")
+	}
+}
+
+func TestProcessFilesHolidayTitle(t *testing.T) {
+	opts := DefaultProcessOptions()
+	opts.Holidays.Custom = []CustomHoliday{{Date: "2025-03-19", Name: "Inventering", RedDay: true}}
+	result, err := ProcessFilesWithOptions(buildInputFile(t, employeeShifts), nil, nil, opts)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	f, err := excelize.OpenReader(bytes.NewReader(result["Vecka 12.xlsx"]))
+	if err != nil {
+		t.Fatalf("opening result: %v", err)
+	}
+	defer f.Close()
+
+	title := func(sheet string) string {
+		rows, _ := f.GetRows(sheet)
+		for _, row := range rows {
+			for _, value := range row {
+				if strings.HasPrefix(value, sheet+" - ") {
+					return value
+				}
+			}
+		}
+		return ""
+	}
+	if got := title("Wednesday"); got != "Wednesday - 2025-03-19 - Inventering" {
+		t.Fatalf("expected holiday in the title, got %q", got)
+	}
+	if got := title("Monday"); got != "Monday - 2025-03-17" {
+		t.Fatalf("expected plain title, got %q", got)
+	}
+}
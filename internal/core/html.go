@@ -0,0 +1,239 @@
+package core
+
+import (
+	"bytes"
+	"fmt"
+	"html/template"
+	"strconv"
+	"strings"
+)
+
+// htmlCell is one cell of the day grid
+type htmlCell struct {
+	Text  string
+	Class string
+}
+
+type htmlNote struct {
+	Text string
+	High bool
+}
+
+type htmlDay struct {
+	Tab         string
+	Title       string
+	Holiday     bool
+	Headers     []string
+	Rows        [][]htmlCell
+	HeaderLines []string
+	FooterLines []string
+	Notes       []htmlNote
+}
+
+type htmlPage struct {
+	Title       string
+	CSS         template.CSS
+	Days        []htmlDay
+	Legend      []htmlCell
+	LegendTitle string
+	NotesTitle  string
+}
+
+var htmlTemplate = template.Must(template.New("week").Parse(`<!DOCTYPE html>
+<html lang="sv">
+<head>
+<meta charset="utf-8">
+<meta name="viewport" content="width=device-width, initial-scale=1">
+<title>{{.Title}}</title>
+<style>{{.CSS}}</style>
+</head>
+<body>
+<h1>{{.Title}}</h1>
+{{range $i, $day := .Days}}<input type="radio" name="day" id="tab-{{$i}}"{{if eq $i 0}} checked{{end}}><label for="tab-{{$i}}">{{$day.Tab}}</label>
+{{end}}<div class="panels">
+{{range $i, $day := .Days}}<section id="day-{{$i}}">
+{{range $day.HeaderLines}}<p class="block">{{.}}</p>
+{{end}}<div class="grid-wrap"><table>
+<caption class="{{if $day.Holiday}}holiday{{else}}title{{end}}">{{$day.Title}}</caption>
+<thead><tr>{{range $day.Headers}}<th class="header">{{.}}</th>{{end}}<th></th></tr></thead>
+<tbody>
+{{range $day.Rows}}<tr>{{range .}}<td{{if .Class}} class="{{.Class}}"{{end}}>{{.Text}}</td>{{end}}</tr>
+{{end}}</tbody>
+<tfoot><tr>{{range $day.Headers}}<th class="header">{{.}}</th>{{end}}<th></th></tr></tfoot>
+</table></div>
+{{if $.Legend}}<table class="legend"><tr><th class="header">{{$.LegendTitle}}</th>{{range $.Legend}}<td class="{{.Class}}">{{.Text}}</td>{{end}}</tr></table>
+{{end}}{{if $day.Notes}}<div class="notes"><div class="header">{{$.NotesTitle}}</div>
+{{range $day.Notes}}<div class="{{if .High}}note-high{{else}}note{{end}}">{{.Text}}</div>
+{{end}}</div>
+{{end}}{{range $day.FooterLines}}<p class="block">{{.}}</p>
+{{end}}</section>
+{{end}}</div>
+</body>
+</html>
+`))
+
+/*
+================================================================================
+Render a week as a self-contained HTML page with a tab per weekday
+================================================================================
+*/
+func renderHTML(title string, views []dayView, rc renderContext) ([]byte, error) {
+	theme := rc.theme
+	roleClass := map[string]string{}
+	for i, role := range theme.roleNames() {
+		roleClass[role] = "role-" + strconv.Itoa(i)
+	}
+
+	page := htmlPage{
+		Title:       title,
+		CSS:         htmlCSS(theme, roleClass, len(views)),
+		LegendTitle: theme.LegendTitle,
+		NotesTitle:  theme.NotesTitle,
+	}
+	if theme.Legend {
+		page.Legend = []htmlCell{
+			{theme.Work.Label, "work"},
+			{theme.Lunch.Label, "lunch"},
+			{theme.Free.Label, "free"},
+			{theme.Assigned.Label, "assigned"},
+		}
+		for _, role := range theme.roleNames() {
+			page.Legend = append(page.Legend, htmlCell{theme.Roles[role].Label, roleClass[role]})
+		}
+	}
+
+	for _, view := range views {
+		data := view.data
+		day := htmlDay{
+			Tab:         data.dayStr,
+			Title:       view.title(),
+			Holiday:     view.holiday != nil,
+			Headers:     data.headers,
+			HeaderLines: nonEmpty(view.header.Lines(view.values)),
+			FooterLines: nonEmpty(view.footer.Lines(view.values)),
+		}
+		for _, shift := range data.shifts {
+			row := []htmlCell{{shift.shiftTime, ""}, {shift.employeeName, "name"}, {shift.phone, ""}}
+			// Skip last hourSchedule since headers compacted by one
+			for _, state := range shift.hourSchedule[:len(shift.hourSchedule)-1] {
+				cell := htmlCell{}
+				switch state {
+				case StateWork:
+					cell.Class = "work"
+				case StateLunch:
+					cell = htmlCell{"Lunch", "lunch"}
+				case StateAssigned:
+					cell = htmlCell{shift.role, "assigned"}
+					if class, ok := roleClass[shift.role]; ok {
+						cell.Class = class
+					}
+				default:
+					cell.Class = "free"
+				}
+				row = append(row, cell)
+			}
+			row = append(row, htmlCell{shift.shiftLength, "total"})
+			day.Rows = append(day.Rows, row)
+		}
+		for _, note := range view.notes {
+			text := note.Text
+			if note.Department != "" {
+				text = note.Department + ": " + text
+			}
+			day.Notes = append(day.Notes, htmlNote{text, note.Priority == PriorityHigh})
+		}
+		page.Days = append(page.Days, day)
+	}
+
+	var buf bytes.Buffer
+	if err := htmlTemplate.Execute(&buf, page); err != nil {
+		return nil, fmt.Errorf("error rendering html: %v", err)
+	}
+	return buf.Bytes(), nil
+}
+
+// htmlCSS builds the stylesheet from the theme, values are parsed so the
+// config cannot inject arbitrary CSS
+func htmlCSS(theme ThemeOptions, roleClass map[string]string, days int) template.CSS {
+	var css strings.Builder
+	css.WriteString(`body{font-family:Arial,Helvetica,sans-serif;margin:1em}
+input[name=day]{display:none}
+label{display:inline-block;padding:.4em .8em;border:1px solid #999;border-bottom:none;cursor:pointer}
+section{display:none;border-top:1px solid #999;padding-top:.5em}
+.grid-wrap{overflow-x:auto}
+table{border-collapse:collapse;margin-bottom:1em}
+td,th{padding:.2em .4em;white-space:nowrap;text-align:center}
+caption{padding:.3em}
+.name{text-align:left}
+.notes div{padding:.3em;white-space:normal}
+`)
+	for i := range days {
+		fmt.Fprintf(&css, "#tab-%d:checked~label[for=tab-%d]{font-weight:bold;background:#eee}\n", i, i)
+		fmt.Fprintf(&css, "#tab-%d:checked~.panels #day-%d{display:block}\n", i, i)
+	}
+	classes := []struct {
+		selector string
+		def      StyleDef
+	}{
+		{".title", theme.Title},
+		{".holiday", theme.Holiday},
+		{".header", theme.Header},
+		{".name", theme.Name},
+		{".free", theme.Free},
+		{".work", theme.Work},
+		{".lunch", theme.Lunch},
+		{".assigned", theme.Assigned},
+		{".note", theme.Note},
+		{".note-high", theme.NoteHigh},
+	}
+	for _, role := range theme.roleNames() {
+		classes = append(classes, struct {
+			selector string
+			def      StyleDef
+		}{"." + roleClass[role], theme.Roles[role]})
+	}
+	for _, c := range classes {
+		fmt.Fprintf(&css, "%s{%s}\n", c.selector, c.def.css())
+	}
+	return template.CSS(css.String())
+}
+
+// css converts the definition into CSS declarations
+func (d StyleDef) css() string {
+	decls := []string{}
+	if d.Fill != "" && d.Pattern != 0 {
+		r, g, b := hexColor(d.Fill, 255)
+		if d.Pattern > 1 {
+			r, g, b = tint(r), tint(g), tint(b)
+		}
+		decls = append(decls, fmt.Sprintf("background:rgb(%d,%d,%d)", r, g, b))
+	}
+	if d.FontColor != "" {
+		r, g, b := hexColor(d.FontColor, 0)
+		decls = append(decls, fmt.Sprintf("color:rgb(%d,%d,%d)", r, g, b))
+	}
+	if d.FontSize > 0 {
+		decls = append(decls, fmt.Sprintf("font-size:%gpt", d.FontSize))
+	}
+	if d.Bold {
+		decls = append(decls, "font-weight:bold")
+	}
+	if d.Italic {
+		decls = append(decls, "font-style:italic")
+	}
+	if width, ok := map[string]string{"thin": "1px solid", "medium": "2px solid", "thick": "3px solid", "dashed": "1px dashed", "dotted": "1px dotted"}[d.Border]; ok {
+		r, g, b := hexColor(d.BorderColor, 0)
+		decls = append(decls, fmt.Sprintf("border:%s rgb(%d,%d,%d)", width, r, g, b))
+	}
+	return strings.Join(decls, ";")
+}
+
+func nonEmpty(lines []string) []string {
+	out := []string{}
+	for _, line := range lines {
+		if line != "" {
+			out = append(out, line)
+		}
+	}
+	return out
+}
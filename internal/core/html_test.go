@@ -0,0 +1,43 @@
+package core
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestProcessFilesHTML(t *testing.T) {
+	input := buildInputFile(t, [][]string{
+		{"1", "Svensson", "Anna", "Pass", "2025-03-17", "09:00 - 17:00", "Kassa"},
+		{"2", "Berg", "Erik", "Pass", "2025-03-18", "12:00 - 16:00", "Kassa"},
+	})
+	opts := DefaultProcessOptions()
+	opts.Formats = []string{"html"}
+	opts.Theme.Legend = true
+	opts.Theme.Roles = map[string]StyleDef{"Kassa": {Fill: "#00FF00", Pattern: 1}}
+	opts.Notes = strings.NewReader("date,text,priority\n2025-03-17,<b>Leverans</b>,high\n")
+	result, err := ProcessFilesWithOptions(input, nil, nil, opts)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(result) != 1 {
+		t.Fatalf("expected only the html file, got %d files", len(result))
+	}
+	page := string(result["Vecka 12.html"])
+	for _, want := range []string{
+		`<label for="tab-0">Monday</label>`,
+		`<label for="tab-1">Tuesday</label>`,
+		"Monday - 2025-03-17",
+		`<td class="name">Anna Svensson</td>`,
+		`<td class="lunch">Lunch</td>`,
+		".role-0{background:rgb(0,255,0);",
+		`<div class="note-high">&lt;b&gt;Leverans&lt;/b&gt;</div>`,
+		"#tab-1:checked~.panels #day-1{display:block}",
+	} {
+		if !strings.Contains(page, want) {
+			t.Fatalf("expected %q in html output", want)
+		}
+	}
+	if strings.Contains(page, "<script") || strings.Contains(page, "<link") {
+		t.Fatalf("html output should be self-contained")
+	}
+}
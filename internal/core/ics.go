@@ -0,0 +1,178 @@
+package core
+
+import (
+	"fmt"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+	_ "time/tzdata" // time zones on Windows without a zoneinfo database
+)
+
+// CalendarOptions controls the iCalendar export
+type CalendarOptions struct {
+	// IANA time zone of the shift times
+	TimeZone string `json:"timeZone" toml:"timeZone" yaml:"timeZone"`
+	// Also write one calendar holding every shift
+	Combined bool `json:"combined" toml:"combined" yaml:"combined"`
+	// Domain part of the event UIDs, keep it fixed so re-imports update events
+	UIDDomain string `json:"uidDomain" toml:"uidDomain" yaml:"uidDomain"`
+}
+
+func defaultCalendarOptions() CalendarOptions {
+	return CalendarOptions{TimeZone: "Europe/Stockholm", UIDDomain: "schedule-helper"}
+}
+
+func (c CalendarOptions) validate() error {
+	if _, err := time.LoadLocation(c.TimeZone); err != nil {
+		return fmt.Errorf("invalid time zone %q: %v", c.TimeZone, err)
+	}
+	if c.UIDDomain == "" {
+		return fmt.Errorf("uidDomain must not be empty")
+	}
+	return nil
+}
+
+/*
+================================================================================
+Create one calendar per employee, and optionally a combined one
+================================================================================
+*/
+func createCalendars(shifts []Shift, opts ProcessOptions) (map[string][]byte, error) {
+	loc, err := time.LoadLocation(opts.Calendar.TimeZone)
+	if err != nil {
+		return nil, fmt.Errorf("invalid time zone: %v", err)
+	}
+	shifts = slices.Clone(shifts)
+	slices.SortStableFunc(shifts, func(a, b Shift) int {
+		return strings.Compare(a.Date+a.StartTime, b.Date+b.StartTime)
+	})
+
+	stamp := time.Now().UTC()
+	byEmployee := map[int][]string{}
+	names := map[int]string{}
+	all := []string{}
+	perDay := map[string]int{}
+	for _, shift := range shifts {
+		// The UID counts the shifts of the employee that day, so a moved
+		// shift keeps its UID and replaces the old event
+		key := strconv.Itoa(shift.EmployeeId) + "-" + shift.Date
+		perDay[key]++
+		uid := fmt.Sprintf("%s-%d@%s", key, perDay[key], opts.Calendar.UIDDomain)
+
+		event, err := shiftEvent(shift, uid, "", loc, stamp, opts)
+		if err != nil {
+			return nil, err
+		}
+		byEmployee[shift.EmployeeId] = append(byEmployee[shift.EmployeeId], event)
+		names[shift.EmployeeId] = shift.Name()
+		if opts.Calendar.Combined {
+			event, _ := shiftEvent(shift, uid, shift.Name()+": ", loc, stamp, opts)
+			all = append(all, event)
+		}
+	}
+
+	files := map[string][]byte{}
+	for id, events := range byEmployee {
+		name := fmt.Sprintf("Kalender %d %s", id, names[id])
+		files[safeFileName(name)+".ics"] = calendar(names[id], opts.Calendar.TimeZone, events)
+	}
+	if opts.Calendar.Combined && len(all) > 0 {
+		files["Kalender alla.ics"] = calendar("Alla pass", opts.Calendar.TimeZone, all)
+	}
+	return files, nil
+}
+
+// shiftEvent renders one VEVENT, times are written in UTC
+func shiftEvent(shift Shift, uid string, prefix string, loc *time.Location, stamp time.Time, opts ProcessOptions) (string, error) {
+	start, err := time.ParseInLocation(time.DateOnly+" "+time.TimeOnly, shift.Date+" "+shift.StartTime, loc)
+	if err != nil {
+		return "", fmt.Errorf("invalid shift start for %d on %s: %v", shift.EmployeeId, shift.Date, err)
+	}
+	end, err := time.ParseInLocation(time.DateOnly+" "+time.TimeOnly, shift.Date+" "+shift.EndTime, loc)
+	if err != nil {
+		return "", fmt.Errorf("invalid shift end for %d on %s: %v", shift.EmployeeId, shift.Date, err)
+	}
+	// Shifts past midnight
+	if !end.After(start) {
+		end = end.AddDate(0, 0, 1)
+	}
+
+	summary := shift.ShiftType
+	if shift.Role != "" {
+		summary += " (" + shift.Role + ")"
+	}
+	description := []string{}
+	if shift.Role != "" {
+		description = append(description, "Roll: "+shift.Role)
+	}
+	if shift.HasLunch {
+		lunch := start.Add(opts.lunchAfter())
+		lunchEnd := lunch.Add(time.Duration(opts.LunchHours * float64(time.Hour)))
+		description = append(description, "Lunch: "+lunch.Format("15:04")+"-"+lunchEnd.Format("15:04"))
+	}
+	description = append(description, "Arbetstid: "+strconv.FormatFloat(shift.ShiftLength, 'f', -1, 64)+" h")
+
+	const utc = "20060102T150405Z"
+	lines := []string{
+		"BEGIN:VEVENT",
+		"UID:" + uid,
+		"DTSTAMP:" + stamp.Format(utc),
+		"DTSTART:" + start.UTC().Format(utc),
+		"DTEND:" + end.UTC().Format(utc),
+		"SUMMARY:" + icsEscape(prefix+summary),
+		"DESCRIPTION:" + icsEscape(strings.Join(description, "\n")),
+	}
+	if shift.Department != "" {
+		lines = append(lines, "LOCATION:"+icsEscape(shift.Department))
+	}
+	lines = append(lines, "END:VEVENT")
+	return strings.Join(lines, "\r\n"), nil
+}
+
+func calendar(name string, timeZone string, events []string) []byte {
+	lines := []string{
+		"BEGIN:VCALENDAR",
+		"VERSION:2.0",
+		"PRODID:-//schedule-helper//Schedule Helper//SV",
+		"CALSCALE:GREGORIAN",
+		"METHOD:PUBLISH",
+		"X-WR-CALNAME:" + icsEscape(name),
+		"X-WR-TIMEZONE:" + timeZone,
+	}
+	lines = append(lines, events...)
+	lines = append(lines, "END:VCALENDAR")
+
+	var b strings.Builder
+	for _, line := range strings.Split(strings.Join(lines, "\r\n"), "\r\n") {
+		b.WriteString(icsFold(line))
+		b.WriteString("\r\n")
+	}
+	return []byte(b.String())
+}
+
+// icsEscape escapes text values as required by RFC 5545
+func icsEscape(s string) string {
+	return strings.NewReplacer(`\`, `\\`, ";", `\;`, ",", `\,`, "\n", `\n`).Replace(s)
+}
+
+// icsFold splits lines longer than 75 octets without breaking UTF-8 runes
+func icsFold(line string) string {
+	var b strings.Builder
+	width := 0
+	for _, r := range line {
+		size := len(string(r))
+		if width+size > 75 {
+			b.WriteString("\r\n ")
+			width = 1
+		}
+		b.WriteRune(r)
+		width += size
+	}
+	return b.String()
+}
+
+// safeFileName replaces characters not allowed in file names
+func safeFileName(name string) string {
+	return strings.NewReplacer("/", "_", `\`, "_", ":", "_", "*", "_", "?", "_", `"`, "_", "<", "_", ">", "_", "|", "_").Replace(name)
+}
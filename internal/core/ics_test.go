@@ -0,0 +1,68 @@
+package core
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestProcessFilesICS(t *testing.T) {
+	input := buildInputFile(t, [][]string{
+		{"1", "Svensson", "Anna", "Pass", "2025-03-17", "09:00 - 17:00", "Kassa"},
+		{"1", "Svensson", "Anna", "Pass", "2025-03-18", "22:00 - 02:00", "Lager"},
+		{"2", "Berg", "Erik", "Pass", "2025-03-18", "12:00 - 16:00", "Kassa"},
+	})
+	opts := DefaultProcessOptions()
+	opts.Formats = []string{"ics"}
+	opts.Calendar.Combined = true
+	result, err := ProcessFilesWithOptions(input, nil, nil, opts)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(result) != 3 {
+		t.Fatalf("expected two employee calendars and a combined one, got %d files", len(result))
+	}
+
+	anna := string(result["Kalender 1 Anna Svensson.ics"])
+	for _, want := range []string{
+		"BEGIN:VCALENDAR\r\n",
+		"UID:1-2025-03-17-1@schedule-helper\r\n",
+		// Stockholm is UTC+1 in March
+		"DTSTART:20250317T080000Z\r\n",
+		"DTEND:20250317T160000Z\r\n",
+		"DESCRIPTION:Lunch: 14:00-15:00\\nArbetstid: 7 h\r\n",
+		"LOCATION:Kassa\r\n",
+		// Past midnight ends the next day
+		"DTEND:20250319T010000Z\r\n",
+	} {
+		if !strings.Contains(anna, want) {
+			t.Fatalf("expected %q in calendar:\n%s", want, anna)
+		}
+	}
+	if strings.Count(anna, "BEGIN:VEVENT") != 2 {
+		t.Fatalf("expected two events for employee 1")
+	}
+	if all := string(result["Kalender alla.ics"]); strings.Count(all, "BEGIN:VEVENT") != 3 || !strings.Contains(all, "SUMMARY:Erik Berg: Pass") {
+		t.Fatalf("unexpected combined calendar:\n%s", all)
+	}
+}
+
+func TestCalendarTimeZone(t *testing.T) {
+	opts := DefaultProcessOptions()
+	opts.Formats = []string{"ics"}
+	opts.Calendar.TimeZone = "Mars/Olympus"
+	if err := opts.Validate(); err == nil {
+		t.Fatalf("expected error for unknown time zone")
+	}
+}
+
+func TestICSFoldAndEscape(t *testing.T) {
+	if got := icsEscape("a,b;c\\d\ne"); got != `a\,b\;c\\d\ne` {
+		t.Fatalf("unexpected escape %q", got)
+	}
+	folded := icsFold("DESCRIPTION:" + strings.Repeat("å", 60))
+	for _, line := range strings.Split(folded, "\r\n") {
+		if len(line) > 75 {
+			t.Fatalf("line longer than 75 octets: %q", line)
+		}
+	}
+}
@@ -0,0 +1,119 @@
+package core
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"slices"
+	"strings"
+
+	"github.com/xuri/excelize/v2"
+)
+
+// Note priorities, higher is shown first
+const (
+	PriorityLow    = -1
+	PriorityNormal = 0
+	PriorityHigh   = 1
+)
+
+// DayNote is a message printed on the day sheet, e.g. a delivery or a visit
+type DayNote struct {
+	Date       string // YYYY-MM-DD
+	Department string // empty applies to every department
+	Text       string
+	Priority   int
+}
+
+// parsePriority accepts words in English and Swedish or the numbers 1-3
+func parsePriority(s string) (int, error) {
+	switch strings.ToLower(strings.TrimSpace(s)) {
+	case "", "normal", "medium", "2":
+		return PriorityNormal, nil
+	case "high", "hög", "hog", "important", "viktig", "!", "1":
+		return PriorityHigh, nil
+	case "low", "låg", "lag", "3":
+		return PriorityLow, nil
+	}
+	return PriorityNormal, fmt.Errorf("invalid priority %q", s)
+}
+
+/*
+================================================================================
+Read the notes file, a table with date, department, text and priority
+================================================================================
+*/
+func readNotes(r io.Reader) (map[string][]DayNote, error) {
+	rows, err := readTable(r)
+	if err != nil {
+		return nil, errors.New("Error reading notes: " + err.Error())
+	}
+	notes := map[string][]DayNote{}
+	for i, record := range tableRecords(rows) {
+		date, err := normalizeDate(record["date"])
+		if err != nil {
+			return nil, fmt.Errorf("Error reading notes row %d: %v", i+2, err)
+		}
+		priority, err := parsePriority(record["priority"])
+		if err != nil {
+			return nil, fmt.Errorf("Error reading notes row %d: %v", i+2, err)
+		}
+		if record["text"] == "" {
+			continue
+		}
+		notes[date] = append(notes[date], DayNote{
+			Date:       date,
+			Department: record["department"],
+			Text:       record["text"],
+			Priority:   priority,
+		})
+	}
+	return notes, nil
+}
+
+// notesForDay returns the notes matching the departments working that day,
+// most important first and otherwise in file order
+func notesForDay(notes []DayNote, departments []string) []DayNote {
+	day := []DayNote{}
+	for _, note := range notes {
+		if note.Department == "" || slices.ContainsFunc(departments, func(d string) bool {
+			return strings.EqualFold(d, note.Department)
+		}) {
+			day = append(day, note)
+		}
+	}
+	slices.SortStableFunc(day, func(a, b DayNote) int { return b.Priority - a.Priority })
+	return day
+}
+
+// writeNotes renders the notes box, a title row followed by one merged row
+// per note spanning the grid width
+func writeNotes(f *excelize.File, sheet string, notes []DayNote, theme ThemeOptions, styles sheetStyles, col int, width int, row int) error {
+	for i := -1; i < len(notes); i++ {
+		start, err := excelize.CoordinatesToCellName(col, row+i+1)
+		if err != nil {
+			return fmt.Errorf("error calculating notes cell: %v", err)
+		}
+		end, _ := excelize.CoordinatesToCellName(col+width-1, row+i+1)
+		if err := f.MergeCell(sheet, start, end); err != nil {
+			return fmt.Errorf("error merging notes cells: %v", err)
+		}
+		if i < 0 {
+			f.SetCellValue(sheet, start, theme.NotesTitle)
+			f.SetCellStyle(sheet, start, end, styles.header)
+			continue
+		}
+		note := notes[i]
+		text := note.Text
+		if note.Department != "" {
+			text = note.Department + ": " + text
+		}
+		style := styles.note
+		if note.Priority == PriorityHigh {
+			style = styles.noteHigh
+		}
+		f.SetCellValue(sheet, start, text)
+		f.SetCellStyle(sheet, start, end, style)
+	}
+	return nil
+}
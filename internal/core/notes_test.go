@@ -0,0 +1,65 @@
+package core
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/xuri/excelize/v2"
+)
+
+func TestReadNotes(t *testing.T) {
+	csv := "date;department;text;priority\n2025-03-17;;Inventering 18:00;\n2025-03-17;Bageri;Leverans 07:00;hög\n2025-03-18;;Tom;\n2025-03-17;;;high\n"
+	notes, err := readNotes(strings.NewReader(csv))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(notes["2025-03-17"]) != 2 || len(notes["2025-03-18"]) != 1 {
+		t.Fatalf("unexpected notes: %v", notes)
+	}
+
+	day := notesForDay(notes["2025-03-17"], []string{"bageri"})
+	if len(day) != 2 || day[0].Text != "Leverans 07:00" || day[0].Priority != PriorityHigh {
+		t.Fatalf("expected high priority bakery note first, got %v", day)
+	}
+	if day := notesForDay(notes["2025-03-17"], []string{"Kassa"}); len(day) != 1 {
+		t.Fatalf("expected department note to be filtered, got %v", day)
+	}
+
+	if _, err := readNotes(strings.NewReader("date,text,priority\n2025-03-17,x,urgent-ish\n")); err == nil {
+		t.Fatalf("expected error for invalid priority")
+	}
+}
+
+func TestProcessFilesWithNotes(t *testing.T) {
+	input := buildInputFile(t, [][]string{
+		{"1", "Svensson", "Anna", "Pass", "2025-03-17", "09:00 - 17:00", "Kassa"},
+		{"2", "Berg", "Erik", "Pass", "2025-03-17", "12:00 - 16:00", "Kassa"},
+	})
+	opts := DefaultProcessOptions()
+	opts.Notes = strings.NewReader("date,department,text,priority\n2025-03-17,,Inventering 18:00,\n2025-03-17,Kassa,VIP-besök,high\n")
+	result, err := ProcessFilesWithOptions(input, nil, nil, opts)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	f, err := excelize.OpenReader(bytes.NewReader(result["Vecka 12.xlsx"]))
+	if err != nil {
+		t.Fatalf("opening result: %v", err)
+	}
+	defer f.Close()
+
+	// Trailing header in row 5, notes box starts after a blank row
+	expect := map[string]string{
+		"A7": "Noteringar",
+		"A8": "Kassa: VIP-besök",
+		"A9": "Inventering 18:00",
+	}
+	for cell, want := range expect {
+		if got, _ := f.GetCellValue("Monday", cell); got != want {
+			t.Fatalf("expected %q in %s, got %q", want, cell, got)
+		}
+	}
+	if !isMerged(f, "Monday", "B8") {
+		t.Fatalf("expected note row to be merged across the grid")
+	}
+}
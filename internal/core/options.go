@@ -0,0 +1,316 @@
+package core
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/BurntSushi/toml"
+	"gopkg.in/yaml.v3"
+)
+
+// ProcessOptions carries all rendering and rule configuration used by
+// ProcessFilesWithOptions. Start from DefaultProcessOptions and override
+// fields, or load them from a TOML, YAML or JSON file.
+type ProcessOptions struct {
+	// Name of the sheet holding the exported shifts in the input file
+	InputSheet string `json:"inputSheet" toml:"inputSheet" yaml:"inputSheet"`
+	// Name of the sheet to copy from the footer file. Variants named
+	// "<sheet>-<date>", "<sheet>-<Weekday>-Dept-<department>", "<sheet>-<Weekday>"
+	// and "<sheet>-Dept-<department>" are preferred in that order when present.
+	FooterSheet string `json:"footerSheet" toml:"footerSheet" yaml:"footerSheet"`
+	// Optional sheet in the footer file placed above the title row
+	HeaderSheet string `json:"headerSheet" toml:"headerSheet" yaml:"headerSheet"`
+	// Output file name per week, {{week}} and {{year}} are replaced
+	FileNamePattern string `json:"fileNamePattern" toml:"fileNamePattern" yaml:"fileNamePattern"`
+	// Output formats: xlsx, pdf, html, ics, json, csv and payroll
+	Formats []string `json:"formats" toml:"formats" yaml:"formats"`
+
+	// Hour slots starting before this time (HH:MM) are not shown
+	HideBefore string `json:"hideBefore" toml:"hideBefore" yaml:"hideBefore"`
+
+	// Shifts longer than this many hours get a lunch break
+	LunchMinShiftHours float64 `json:"lunchMinShiftHours" toml:"lunchMinShiftHours" yaml:"lunchMinShiftHours"`
+	// Lunch is placed this many hours after shift start
+	LunchAfterHours float64 `json:"lunchAfterHours" toml:"lunchAfterHours" yaml:"lunchAfterHours"`
+	// Hours deducted from the shift length for lunch
+	LunchHours float64 `json:"lunchHours" toml:"lunchHours" yaml:"lunchHours"`
+
+	// Prototype sheet in the layout template
+	TemplateSheet string `json:"templateSheet" toml:"templateSheet" yaml:"templateSheet"`
+
+	// Fixed column headers in front of the hour slots
+	TimeHeader  string `json:"timeHeader" toml:"timeHeader" yaml:"timeHeader"`
+	NameHeader  string `json:"nameHeader" toml:"nameHeader" yaml:"nameHeader"`
+	PhoneHeader string `json:"phoneHeader" toml:"phoneHeader" yaml:"phoneHeader"`
+	// Column headers of the employee view and the overview
+	DayHeader   string `json:"dayHeader" toml:"dayHeader" yaml:"dayHeader"`
+	DateHeader  string `json:"dateHeader" toml:"dateHeader" yaml:"dateHeader"`
+	TotalHeader string `json:"totalHeader" toml:"totalHeader" yaml:"totalHeader"`
+	// Row label of the daily head count in the overview
+	HeadcountHeader string `json:"headcountHeader" toml:"headcountHeader" yaml:"headcountHeader"`
+
+	// Prepend a sheet with every employee against the days of the week
+	Overview bool `json:"overview" toml:"overview" yaml:"overview"`
+	// Per employee weeks: "" (off), "sheets" or "workbooks"
+	EmployeeView string `json:"employeeView" toml:"employeeView" yaml:"employeeView"`
+
+	// Colours and legend of the day sheets
+	Theme ThemeOptions `json:"theme" toml:"theme" yaml:"theme"`
+	// Print setup of the day sheets
+	Page PageOptions `json:"page" toml:"page" yaml:"page"`
+	// Calendar files per employee
+	Calendar CalendarOptions `json:"calendar" toml:"calendar" yaml:"calendar"`
+	// Pay categories of the payroll export
+	Payroll PayrollOptions `json:"payroll" toml:"payroll" yaml:"payroll"`
+	// Public holidays marked on the sheets and used for pay rules
+	Holidays HolidayOptions `json:"holidays" toml:"holidays" yaml:"holidays"`
+	// Labour cost estimate from the hourly wages in the settings file
+	Cost CostOptions `json:"cost" toml:"cost" yaml:"cost"`
+	// Staffing compared against the demand file
+	Staffing StaffingOptions `json:"staffing" toml:"staffing" yaml:"staffing"`
+	// Working-time rules of the schedule generator
+	Generator GeneratorOptions `json:"generator" toml:"generator" yaml:"generator"`
+	// Expansion of the base schedule file
+	Rotation RotationOptions `json:"rotation" toml:"rotation" yaml:"rotation"`
+	// Comparison of the shifts with the punches file
+	TimeClock TimeClockOptions `json:"timeClock" toml:"timeClock" yaml:"timeClock"`
+	// Change report against the previous export
+	Diff DiffOptions `json:"diff" toml:"diff" yaml:"diff"`
+
+	// Optional data sources, set by the caller and not part of the config file
+
+	// Layout template (*.xlsx) with a prototype day sheet
+	Template io.Reader `json:"-" toml:"-" yaml:"-"`
+	// Per-date placeholder values (*.xlsx or *.csv), a date column plus one column per name
+	DayValues io.Reader `json:"-" toml:"-" yaml:"-"`
+	// Daily notes (*.xlsx or *.csv) with date, department, text and priority columns
+	Notes io.Reader `json:"-" toml:"-" yaml:"-"`
+	// Staffing demand (*.xlsx or *.csv) with date, slot and required or transactions columns
+	Demand io.Reader `json:"-" toml:"-" yaml:"-"`
+	// Absences (*.xlsx or *.csv) with employeeId, from, to, optional hours and type
+	Absences io.Reader `json:"-" toml:"-" yaml:"-"`
+	// Time-clock punches (*.xlsx or *.csv) with employeeId, date, clock-in and clock-out
+	Punches io.Reader `json:"-" toml:"-" yaml:"-"`
+	// Earlier export of the same period (*.xlsx), changed shifts are highlighted and reported
+	Previous io.Reader `json:"-" toml:"-" yaml:"-"`
+	// Rotating base schedule (*.xlsx or *.csv) expanded into shifts and merged with the export
+	BaseSchedule io.Reader `json:"-" toml:"-" yaml:"-"`
+}
+
+// Output formats
+const (
+	FormatXLSX = "xlsx"
+	FormatPDF  = "pdf"
+	FormatHTML = "html"
+	FormatICS  = "ics"
+	FormatJSON = "json"
+	FormatCSV  = "csv"
+	// Payroll totals as CSV and PAXml
+	FormatPayroll = "payroll"
+)
+
+var outputFormats = []string{FormatXLSX, FormatPDF, FormatHTML, FormatICS, FormatJSON, FormatCSV, FormatPayroll}
+
+// OptionalSource describes a data file that can accompany an export
+type OptionalSource struct {
+	Key   string // CLI flag, upload field "<key>File" and file "<key>.xlsx/.csv" in a watched folder
+	Label string // shown in the GUI
+	Help  string
+	Set   func(opts *ProcessOptions, r io.Reader)
+}
+
+// OptionalSources lists the optional data files in the order they are shown
+var OptionalSources = []OptionalSource{
+	{"template", "Template File", "layout template with a prototype day sheet (*.xlsx)",
+		func(o *ProcessOptions, r io.Reader) { o.Template = r }},
+	{"values", "Day Values File", "per-date placeholder values (*.xlsx or *.csv)",
+		func(o *ProcessOptions, r io.Reader) { o.DayValues = r }},
+	{"notes", "Notes File", "daily notes with date, department, text and priority (*.xlsx or *.csv)",
+		func(o *ProcessOptions, r io.Reader) { o.Notes = r }},
+	{"demand", "Demand File", "staffing demand per date and hour slot, required staff or transactions (*.xlsx or *.csv)",
+		func(o *ProcessOptions, r io.Reader) { o.Demand = r }},
+	{"base", "Base Schedule File", "rotating base schedule with employee, cycleWeek, weekday, time and role (*.xlsx or *.csv)",
+		func(o *ProcessOptions, r io.Reader) { o.BaseSchedule = r }},
+	{"absences", "Absences File", "vacation and other absences with employeeId, from, to, optional hours and type (*.xlsx or *.csv)",
+		func(o *ProcessOptions, r io.Reader) { o.Absences = r }},
+	{"punches", "Punches File", "time-clock punches with employeeId, date, clock-in and clock-out, compared with the shifts (*.xlsx or *.csv)",
+		func(o *ProcessOptions, r io.Reader) { o.Punches = r }},
+	{"previous", "Previous Export", "earlier export of the same period, changed shifts are highlighted and reported (*.xlsx)",
+		func(o *ProcessOptions, r io.Reader) { o.Previous = r }},
+}
+
+// DefaultProcessOptions returns the options matching the original hard-coded behaviour
+func DefaultProcessOptions() ProcessOptions {
+	return ProcessOptions{
+		InputSheet:         "Worksheet",
+		FooterSheet:        "Footer",
+		HeaderSheet:        "Header",
+		TemplateSheet:      "Day",
+		FileNamePattern:    "Vecka {{week}}",
+		Formats:            []string{FormatXLSX},
+		HideBefore:         "10:00",
+		LunchMinShiftHours: 5,
+		LunchAfterHours:    5,
+		LunchHours:         1,
+		TimeHeader:         "Arbetstid",
+		NameHeader:         "Namn",
+		PhoneHeader:        "Tele",
+		DayHeader:          "Dag",
+		DateHeader:         "Datum",
+		TotalHeader:        "Timmar",
+		HeadcountHeader:    "Antal",
+		Page:               defaultPageOptions(),
+		Calendar:           defaultCalendarOptions(),
+		Payroll:            defaultPayrollOptions(),
+		Holidays:           defaultHolidayOptions(),
+		Cost:               defaultCostOptions(),
+		Staffing:           defaultStaffingOptions(),
+		Generator:          defaultGeneratorOptions(),
+		Rotation:           defaultRotationOptions(),
+		TimeClock:          defaultTimeClockOptions(),
+		Diff:               defaultDiffOptions(),
+	}
+}
+
+// LoadProcessOptions decodes options on top of the defaults.
+// Format is one of "toml", "yaml", "yml" or "json".
+func LoadProcessOptions(r io.Reader, format string) (ProcessOptions, error) {
+	opts := DefaultProcessOptions()
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return opts, errors.New("Error reading options: " + err.Error())
+	}
+
+	switch strings.ToLower(strings.TrimPrefix(format, ".")) {
+	case "toml":
+		_, err = toml.NewDecoder(bytes.NewReader(data)).Decode(&opts)
+	case "yaml", "yml":
+		err = yaml.Unmarshal(data, &opts)
+	case "json":
+		err = json.Unmarshal(data, &opts)
+	default:
+		return opts, fmt.Errorf("unsupported options format %q", format)
+	}
+	if err != nil {
+		return opts, errors.New("Error decoding options: " + err.Error())
+	}
+
+	return opts, opts.Validate()
+}
+
+// LoadProcessOptionsFile reads options from a file, format taken from the extension
+func LoadProcessOptionsFile(path string) (ProcessOptions, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return DefaultProcessOptions(), errors.New("Error opening options file: " + err.Error())
+	}
+	defer f.Close()
+
+	return LoadProcessOptions(f, filepath.Ext(path))
+}
+
+// Validate checks that the options can be used for processing
+func (o ProcessOptions) Validate() error {
+	if _, err := o.hideBeforeTime(); err != nil {
+		return fmt.Errorf("invalid hideBefore %q: %v", o.HideBefore, err)
+	}
+	if o.LunchHours < 0 || o.LunchAfterHours < 0 || o.LunchMinShiftHours < 0 {
+		return errors.New("lunch hours must not be negative")
+	}
+	if o.FileNamePattern == "" {
+		return errors.New("fileNamePattern must not be empty")
+	}
+	if _, err := resolveTheme(o.Theme); err != nil {
+		return fmt.Errorf("invalid theme: %v", err)
+	}
+	for _, format := range o.formats() {
+		if !slices.Contains(outputFormats, format) {
+			return fmt.Errorf("unknown output format %q", format)
+		}
+	}
+	if o.EmployeeView != "" && o.EmployeeView != EmployeeViewSheets && o.EmployeeView != EmployeeViewWorkbooks {
+		return fmt.Errorf("employeeView must be %s or %s, got %q", EmployeeViewSheets, EmployeeViewWorkbooks, o.EmployeeView)
+	}
+	if err := o.Holidays.validate(); err != nil {
+		return fmt.Errorf("invalid holidays: %v", err)
+	}
+	if err := o.Page.validate(); err != nil {
+		return fmt.Errorf("invalid page setup: %v", err)
+	}
+	if slices.Contains(o.formats(), FormatICS) {
+		if err := o.Calendar.validate(); err != nil {
+			return fmt.Errorf("invalid calendar options: %v", err)
+		}
+	}
+	// The cost estimate prices the payroll's pay categories
+	if slices.Contains(o.formats(), FormatPayroll) || o.Cost.Enabled {
+		if err := o.Payroll.validate(); err != nil {
+			return fmt.Errorf("invalid payroll options: %v", err)
+		}
+	}
+	if o.BaseSchedule != nil {
+		if err := o.Rotation.validate(); err != nil {
+			return fmt.Errorf("invalid rotation options: %v", err)
+		}
+	}
+	if o.Punches != nil {
+		if err := o.TimeClock.validate(); err != nil {
+			return fmt.Errorf("invalid time clock options: %v", err)
+		}
+	}
+	if err := o.Diff.validate(); err != nil {
+		return fmt.Errorf("invalid diff options: %v", err)
+	}
+	if err := o.Staffing.validate(); err != nil {
+		return fmt.Errorf("invalid staffing options: %v", err)
+	}
+	if o.Cost.Enabled {
+		if err := o.Cost.validate(); err != nil {
+			return fmt.Errorf("invalid cost options: %v", err)
+		}
+	}
+	return nil
+}
+
+// formats returns the output formats in lower case, xlsx when none are set
+func (o ProcessOptions) formats() []string {
+	if len(o.Formats) == 0 {
+		return []string{FormatXLSX}
+	}
+	formats := []string{}
+	for _, format := range o.Formats {
+		format = strings.ToLower(strings.TrimPrefix(strings.TrimSpace(format), "."))
+		if !slices.Contains(formats, format) {
+			formats = append(formats, format)
+		}
+	}
+	return formats
+}
+
+func (o ProcessOptions) hideBeforeTime() (time.Time, error) {
+	if o.HideBefore == "" {
+		return time.Parse(time.TimeOnly, "00:00:00")
+	}
+	return time.Parse("15:04", o.HideBefore)
+}
+
+func (o ProcessOptions) lunchAfter() time.Duration {
+	return time.Duration(o.LunchAfterHours * float64(time.Hour))
+}
+
+func (o ProcessOptions) headers() []string {
+	return []string{o.TimeHeader, o.NameHeader, o.PhoneHeader}
+}
+
+// fileName builds the output name for a week, without extension
+func (o ProcessOptions) fileName(week string, year string) string {
+	return strings.NewReplacer("{{week}}", week, "{{year}}", year).Replace(o.FileNamePattern)
+}
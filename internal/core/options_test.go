@@ -0,0 +1,62 @@
+package core
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/go-gota/gota/series"
+)
+
+func TestLoadProcessOptionsFormats(t *testing.T) {
+	cases := map[string]string{
+		"toml": "hideBefore = \"08:00\"\nnameHeader = \"Name\"\n",
+		"yaml": "hideBefore: \"08:00\"\nnameHeader: Name\n",
+		"json": `{"hideBefore": "08:00", "nameHeader": "Name"}`,
+	}
+	for format, content := range cases {
+		opts, err := LoadProcessOptions(strings.NewReader(content), format)
+		if err != nil {
+			t.Fatalf("%s: unexpected error: %v", format, err)
+		}
+		if opts.HideBefore != "08:00" || opts.NameHeader != "Name" {
+			t.Fatalf("%s: options not decoded: %+v", format, opts)
+		}
+		// Untouched fields keep their defaults
+		if opts.TimeHeader != "Arbetstid" || opts.LunchAfterHours != 5 {
+			t.Fatalf("%s: defaults not kept: %+v", format, opts)
+		}
+	}
+}
+
+func TestLoadProcessOptionsInvalid(t *testing.T) {
+	if _, err := LoadProcessOptions(strings.NewReader(`{"hideBefore": "soon"}`), "json"); err == nil {
+		t.Fatalf("expected error for invalid hideBefore")
+	}
+	if _, err := LoadProcessOptions(strings.NewReader(""), "ini"); err == nil {
+		t.Fatalf("expected error for unsupported format")
+	}
+}
+
+func TestProcessOptionsFileName(t *testing.T) {
+	opts := DefaultProcessOptions()
+	if got := opts.fileName("12", "2025"); got != "Vecka 12" {
+		t.Fatalf("unexpected default file name: %s", got)
+	}
+	opts.FileNamePattern = "{{year}}-W{{week}}"
+	if got := opts.fileName("12", "2025"); got != "2025-W12" {
+		t.Fatalf("unexpected file name: %s", got)
+	}
+}
+
+func TestExtractShiftDetailsLunchRule(t *testing.T) {
+	opts := DefaultProcessOptions()
+	opts.LunchMinShiftHours = 3
+	opts.LunchHours = 0.5
+	out, err := extractShiftDetails(series.New([]string{"09:00 - 13:00"}, series.String, "time"), opts)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if out[2].Records()[0] != "3.500000" || out[3].Records()[0] != "true" {
+		t.Fatalf("lunch rule not applied: %v %v", out[2].Records(), out[3].Records())
+	}
+}
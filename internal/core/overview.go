@@ -0,0 +1,144 @@
+package core
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/xuri/excelize/v2"
+)
+
+// Name of the week overview sheet
+const overviewSheet = "Overview"
+
+/*
+================================================================================
+Overview sheet: every employee against the days of the week
+================================================================================
+*/
+func writeOverviewSheet(f *excelize.File, week weekView, rc renderContext) error {
+	if len(week.employees) == 0 {
+		return nil
+	}
+	if _, err := f.NewSheet(overviewSheet); err != nil {
+		return fmt.Errorf("error creating overview sheet: %v", err)
+	}
+	cell := func(col, row int) string {
+		name, _ := excelize.CoordinatesToCellName(col, row)
+		return name
+	}
+	days := week.employees[0].dayDates()
+	lastCol := len(days) + 2
+
+	// Title and a header per day
+	f.MergeCell(overviewSheet, cell(1, 1), cell(lastCol, 1))
+	f.SetCellValue(overviewSheet, cell(1, 1), week.name)
+	f.SetCellStyle(overviewSheet, cell(1, 1), cell(lastCol, 1), rc.styles.title)
+	f.SetCellValue(overviewSheet, cell(1, 2), rc.opts.NameHeader)
+	f.SetCellValue(overviewSheet, cell(lastCol, 2), rc.opts.TotalHeader)
+	f.SetCellStyle(overviewSheet, cell(1, 2), cell(lastCol, 2), rc.styles.header)
+	for i, day := range days {
+		header := day.day + " " + day.date
+		if holiday, ok := rc.holidays.Lookup(day.date); ok {
+			header += " " + holiday.Name
+			f.SetCellStyle(overviewSheet, cell(i+2, 2), cell(i+2, 2), rc.styles.holiday)
+		}
+		f.SetCellValue(overviewSheet, cell(i+2, 2), header)
+	}
+
+	// A row per employee
+	headcount := make([]int, len(days))
+	hours := make([]float64, len(days))
+	row := 3
+	for _, employee := range week.employees {
+		f.SetCellValue(overviewSheet, cell(1, row), employee.name)
+		f.SetCellStyle(overviewSheet, cell(1, row), cell(1, row), rc.styles.name)
+		for i, day := range days {
+			shifts := []string{}
+			for _, d := range employee.days {
+				if d.date != day.date || d.shift == nil {
+					continue
+				}
+				shifts = append(shifts, overviewShift(*d.shift))
+				length, _ := strconv.ParseFloat(d.shift.shiftLength, 64)
+				hours[i] += length
+			}
+			style := rc.styles.work
+			if len(shifts) == 0 {
+				shifts = append(shifts, rc.theme.Free.Label)
+				style = rc.styles.free
+			} else {
+				headcount[i]++
+			}
+			f.SetCellValue(overviewSheet, cell(i+2, row), strings.Join(shifts, ", "))
+			f.SetCellStyle(overviewSheet, cell(i+2, row), cell(i+2, row), style)
+		}
+		f.SetCellValue(overviewSheet, cell(lastCol, row), employee.total)
+		row++
+	}
+
+	// Head count and hours per day
+	totalHours := 0.0
+	f.SetCellValue(overviewSheet, cell(1, row), rc.opts.HeadcountHeader)
+	f.SetCellValue(overviewSheet, cell(1, row+1), rc.opts.TotalHeader)
+	for i := range days {
+		f.SetCellValue(overviewSheet, cell(i+2, row), headcount[i])
+		f.SetCellValue(overviewSheet, cell(i+2, row+1), hours[i])
+		totalHours += hours[i]
+	}
+	f.SetCellValue(overviewSheet, cell(lastCol, row+1), totalHours)
+	lastRow := row + 1
+
+	// Labour cost per day and for the week
+	if rc.costs != nil {
+		lastRow++
+		weekCost := 0.0
+		f.SetCellValue(overviewSheet, cell(1, lastRow), rc.opts.Cost.Header)
+		for i, day := range days {
+			cost := rc.costs.day(day.date)
+			f.SetCellValue(overviewSheet, cell(i+2, lastRow), roundCost(cost))
+			weekCost += cost
+		}
+		f.SetCellValue(overviewSheet, cell(lastCol, lastRow), roundCost(weekCost))
+	}
+	f.SetCellStyle(overviewSheet, cell(1, row), cell(lastCol, lastRow), rc.styles.header)
+
+	f.SetColWidth(overviewSheet, "A", "A", 25)
+	last, _ := excelize.ColumnNumberToName(lastCol - 1)
+	f.SetColWidth(overviewSheet, "B", last, 22)
+
+	// First sheet, and the one shown when the workbook opens
+	first := f.GetSheetList()[0]
+	if err := f.MoveSheet(overviewSheet, first); err != nil {
+		return fmt.Errorf("error moving overview sheet: %v", err)
+	}
+	f.SetActiveSheet(0)
+	return nil
+}
+
+// dayDates lists the seven days of the week, once each
+func (w employeeWeek) dayDates() []employeeDay {
+	days := []employeeDay{}
+	for _, d := range w.days {
+		if len(days) == 0 || days[len(days)-1].date != d.date {
+			days = append(days, employeeDay{day: d.day, date: d.date})
+		}
+	}
+	return days
+}
+
+// overviewShift gives a short form like "09-17 Kassa", or "09:30-17 Kassa"
+func overviewShift(shift ShiftActivity) string {
+	times := strings.Split(shift.shiftTime, "-")
+	for i, t := range times {
+		times[i] = strings.TrimSuffix(strings.TrimSpace(t), ":00")
+	}
+	text := strings.Join(times, "-")
+	if shift.role != "" {
+		return text + " " + shift.role
+	}
+	if shift.department != "" {
+		return text + " " + shift.department
+	}
+	return text
+}
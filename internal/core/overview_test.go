@@ -0,0 +1,60 @@
+package core
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/xuri/excelize/v2"
+)
+
+func TestProcessFilesOverview(t *testing.T) {
+	opts := DefaultProcessOptions()
+	opts.Overview = true
+	result, err := ProcessFilesWithOptions(buildInputFile(t, employeeShifts), nil, nil, opts)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	f, err := excelize.OpenReader(bytes.NewReader(result["Vecka 12.xlsx"]))
+	if err != nil {
+		t.Fatalf("opening result: %v", err)
+	}
+	defer f.Close()
+
+	if sheets := f.GetSheetList(); sheets[0] != "Overview" || sheets[1] != "Monday" || len(sheets) != 4 {
+		t.Fatalf("expected overview first, got %v", sheets)
+	}
+	if f.GetActiveSheetIndex() != 0 {
+		t.Fatalf("expected overview to be the active sheet")
+	}
+
+	expect := map[string]string{
+		"A1": "Vecka 12",
+		"B2": "Monday 2025-03-17",
+		"H2": "Sunday 2025-03-23",
+		"A3": "Anna Svensson",
+		"B3": "09-17 Kassa",
+		"C3": "Ledig",
+		"D3": "12-16 Kassa",
+		"I3": "11",
+		"A4": "Erik Berg",
+		"C4": "10-14 Kassa",
+		"A5": "Antal",
+		"B5": "1",
+		"H5": "0",
+		"A6": "Timmar",
+		"B6": "7",
+		"I6": "15",
+	}
+	for cell, value := range expect {
+		if got, _ := f.GetCellValue("Overview", cell); got != value {
+			t.Fatalf("expected %q in %s, got %q", value, cell, got)
+		}
+	}
+
+	// Print names still point at the day sheets after the overview was prepended
+	for _, dn := range f.GetDefinedName() {
+		if dn.Name == "_xlnm.Print_Area" && dn.Scope == "Overview" {
+			t.Fatalf("print area of a day sheet is scoped to the overview")
+		}
+	}
+}
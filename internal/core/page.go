@@ -0,0 +1,175 @@
+package core
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/xuri/excelize/v2"
+)
+
+// PageOptions controls how the day sheets print
+type PageOptions struct {
+	// portrait or landscape
+	Orientation string `json:"orientation" toml:"orientation" yaml:"orientation"`
+	// A3, A4, A5, letter or legal
+	PaperSize string `json:"paperSize" toml:"paperSize" yaml:"paperSize"`
+	// Scale the sheet to one page wide
+	FitToWidth bool `json:"fitToWidth" toml:"fitToWidth" yaml:"fitToWidth"`
+	// Margins in centimetres
+	Margins PageMargins `json:"margins" toml:"margins" yaml:"margins"`
+	// Repeat the title and header rows on every printed page
+	RepeatHeader bool `json:"repeatHeader" toml:"repeatHeader" yaml:"repeatHeader"`
+	// Limit printing to the grid, notes and footer
+	PrintArea bool `json:"printArea" toml:"printArea" yaml:"printArea"`
+	// Page header and footer, Excel codes like &L, &C, &R, &P and &N plus
+	// placeholders such as {{week}} and {{date}}
+	Header string `json:"header" toml:"header" yaml:"header"`
+	Footer string `json:"footer" toml:"footer" yaml:"footer"`
+	// Hide the cell gridlines
+	HideGridlines bool `json:"hideGridlines" toml:"hideGridlines" yaml:"hideGridlines"`
+}
+
+// PageMargins in centimetres
+type PageMargins struct {
+	Top    float64 `json:"top" toml:"top" yaml:"top"`
+	Bottom float64 `json:"bottom" toml:"bottom" yaml:"bottom"`
+	Left   float64 `json:"left" toml:"left" yaml:"left"`
+	Right  float64 `json:"right" toml:"right" yaml:"right"`
+	Header float64 `json:"header" toml:"header" yaml:"header"`
+	Footer float64 `json:"footer" toml:"footer" yaml:"footer"`
+}
+
+// Excel paper size codes
+var paperSizes = map[string]int{
+	"letter": 1,
+	"legal":  5,
+	"a3":     8,
+	"a4":     9,
+	"a5":     11,
+}
+
+func defaultPageOptions() PageOptions {
+	return PageOptions{
+		Orientation:  "landscape",
+		PaperSize:    "A4",
+		FitToWidth:   true,
+		Margins:      PageMargins{Top: 1.5, Bottom: 1.5, Left: 1, Right: 1, Header: 0.8, Footer: 0.8},
+		RepeatHeader: true,
+		PrintArea:    true,
+		Header:       "&L{{weekday}} {{date}}&RVecka {{week}}",
+		Footer:       "&CSida &P av &N",
+	}
+}
+
+func (p PageOptions) validate() error {
+	if p.Orientation != "portrait" && p.Orientation != "landscape" {
+		return fmt.Errorf("orientation must be portrait or landscape, got %q", p.Orientation)
+	}
+	if _, ok := paperSizes[strings.ToLower(p.PaperSize)]; !ok {
+		return fmt.Errorf("unknown paper size %q", p.PaperSize)
+	}
+	m := p.Margins
+	for _, v := range []float64{m.Top, m.Bottom, m.Left, m.Right, m.Header, m.Footer} {
+		if v < 0 {
+			return fmt.Errorf("margins must not be negative")
+		}
+	}
+	return nil
+}
+
+// printRange is what a day sheet prints, kept until the sheets are sorted
+// since the print names are bound to the sheet position
+type printRange struct {
+	titleRow  int
+	headerRow int
+	lastCol   int
+	lastRow   int
+}
+
+/*
+================================================================================
+Page setup of a day sheet
+================================================================================
+*/
+func applyPageSetup(f *excelize.File, sheet string, page PageOptions, templated bool, values map[string]string) error {
+	if page.HideGridlines {
+		if err := f.SetSheetView(sheet, 0, &excelize.ViewOptions{ShowGridLines: boolPtr(false)}); err != nil {
+			return fmt.Errorf("error hiding gridlines: %v", err)
+		}
+	}
+	// The template brings its own page layout
+	if templated {
+		return nil
+	}
+
+	size := paperSizes[strings.ToLower(page.PaperSize)]
+	layout := &excelize.PageLayoutOptions{Size: &size, Orientation: &page.Orientation}
+	if page.FitToWidth {
+		one, auto := 1, 0
+		layout.FitToWidth, layout.FitToHeight = &one, &auto
+		if err := f.SetSheetProps(sheet, &excelize.SheetPropsOptions{FitToPage: boolPtr(true)}); err != nil {
+			return fmt.Errorf("error setting fit to page: %v", err)
+		}
+	}
+	if err := f.SetPageLayout(sheet, layout); err != nil {
+		return fmt.Errorf("error setting page layout: %v", err)
+	}
+
+	// excelize wants inches
+	inch := func(cm float64) *float64 { v := cm / 2.54; return &v }
+	m := page.Margins
+	if err := f.SetPageMargins(sheet, &excelize.PageLayoutMarginsOptions{
+		Top: inch(m.Top), Bottom: inch(m.Bottom), Left: inch(m.Left), Right: inch(m.Right),
+		Header: inch(m.Header), Footer: inch(m.Footer),
+	}); err != nil {
+		return fmt.Errorf("error setting page margins: %v", err)
+	}
+
+	if page.Header != "" || page.Footer != "" {
+		// & starts a code in page headers, literal ones are doubled
+		escaped := map[string]string{}
+		for k, v := range values {
+			escaped[k] = strings.ReplaceAll(v, "&", "&&")
+		}
+		if err := f.SetHeaderFooter(sheet, &excelize.HeaderFooterOptions{
+			OddHeader: replacePlaceholders(page.Header, escaped),
+			OddFooter: replacePlaceholders(page.Footer, escaped),
+		}); err != nil {
+			return fmt.Errorf("error setting page header: %v", err)
+		}
+	}
+	return nil
+}
+
+// setPrintNames defines the print area and repeated rows, call after the
+// sheets have reached their final position
+func setPrintNames(f *excelize.File, sheet string, page PageOptions, pr printRange) error {
+	quoted := "'" + strings.ReplaceAll(sheet, "'", "''") + "'"
+	if page.PrintArea && pr.lastRow > 0 {
+		lastCol, err := excelize.ColumnNumberToName(pr.lastCol)
+		if err != nil {
+			return err
+		}
+		if err := f.SetDefinedName(&excelize.DefinedName{
+			Name:     "_xlnm.Print_Area",
+			RefersTo: fmt.Sprintf("%s!$A$1:$%s$%d", quoted, lastCol, pr.lastRow),
+			Scope:    sheet,
+		}); err != nil {
+			return fmt.Errorf("error setting print area: %v", err)
+		}
+	}
+	if page.RepeatHeader && pr.headerRow > 0 {
+		if err := f.SetDefinedName(&excelize.DefinedName{
+			Name:     "_xlnm.Print_Titles",
+			RefersTo: fmt.Sprintf("%s!$%d:$%d", quoted, pr.titleRow, pr.headerRow),
+			Scope:    sheet,
+		}); err != nil {
+			return fmt.Errorf("error setting print titles: %v", err)
+		}
+	}
+	return nil
+}
+
+func boolPtr(b bool) *bool {
+	return &b
+}
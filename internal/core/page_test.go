@@ -0,0 +1,80 @@
+package core
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/xuri/excelize/v2"
+)
+
+func TestProcessFilesPageSetup(t *testing.T) {
+	input := buildInputFile(t, [][]string{
+		{"1", "Svensson", "Anna", "Pass", "2025-03-18", "09:00 - 17:00", "Kassa"},
+		{"2", "Berg", "Erik", "Pass", "2025-03-17", "12:00 - 16:00", "Kassa"},
+		{"3", "Ek", "Sara", "Pass", "2025-03-17", "10:00 - 14:00", "Kassa"},
+	})
+	opts := DefaultProcessOptions()
+	opts.Page.HideGridlines = true
+	result, err := ProcessFilesWithOptions(input, nil, nil, opts)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	f, err := excelize.OpenReader(bytes.NewReader(result["Vecka 12.xlsx"]))
+	if err != nil {
+		t.Fatalf("opening result: %v", err)
+	}
+	defer f.Close()
+
+	layout, _ := f.GetPageLayout("Monday")
+	if layout.Orientation == nil || *layout.Orientation != "landscape" || layout.Size == nil || *layout.Size != 9 {
+		t.Fatalf("expected landscape A4, got %+v", layout)
+	}
+	if layout.FitToWidth == nil || *layout.FitToWidth != 1 {
+		t.Fatalf("expected fit to one page wide, got %+v", layout)
+	}
+	hf, _ := f.GetHeaderFooter("Monday")
+	if !strings.Contains(hf.OddHeader, "2025-03-17") || !strings.Contains(hf.OddHeader, "Vecka 12") {
+		t.Fatalf("unexpected page header %q", hf.OddHeader)
+	}
+	view, _ := f.GetSheetView("Monday", 0)
+	if view.ShowGridLines == nil || *view.ShowGridLines {
+		t.Fatalf("expected gridlines to be hidden")
+	}
+
+	// Print names must follow the sheets to their sorted position
+	want := map[string]string{
+		"Monday":  "'Monday'!$A$1:$",
+		"Tuesday": "'Tuesday'!$A$1:$",
+	}
+	found := map[string]bool{}
+	for _, dn := range f.GetDefinedName() {
+		switch dn.Name {
+		case "_xlnm.Print_Area":
+			if !strings.HasPrefix(dn.RefersTo, want[dn.Scope]) {
+				t.Fatalf("print area %q scoped to %q", dn.RefersTo, dn.Scope)
+			}
+			found[dn.Scope] = true
+		case "_xlnm.Print_Titles":
+			if dn.RefersTo != "'"+dn.Scope+"'!$1:$2" {
+				t.Fatalf("unexpected print titles %q for %q", dn.RefersTo, dn.Scope)
+			}
+		}
+	}
+	if !found["Monday"] || !found["Tuesday"] {
+		t.Fatalf("expected print areas for both days, got %v", f.GetDefinedName())
+	}
+}
+
+func TestPageOptionsValidate(t *testing.T) {
+	opts := DefaultProcessOptions()
+	opts.Page.PaperSize = "B7"
+	if err := opts.Validate(); err == nil {
+		t.Fatalf("expected error for unknown paper size")
+	}
+	opts = DefaultProcessOptions()
+	opts.Page.Orientation = "sideways"
+	if err := opts.Validate(); err == nil {
+		t.Fatalf("expected error for unknown orientation")
+	}
+}
@@ -0,0 +1,388 @@
+package core
+
+import (
+	"bytes"
+	"encoding/xml"
+	"fmt"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Fixed pay categories, rule categories are listed between them
+const (
+	PayRegular  = "regular"
+	PayOvertime = "overtime"
+)
+
+// Payroll periods
+const (
+	PeriodWeek  = "week"
+	PeriodMonth = "month"
+)
+
+// PayRule puts the minutes inside a time window into a category.
+// Rules are checked in order and the first match wins.
+type PayRule struct {
+	Category string `json:"category" toml:"category" yaml:"category"`
+	// Salary type code (löneart) used in the PAXml export
+	Code string `json:"code" toml:"code" yaml:"code"`
+	// English weekday names or "holiday", empty matches every day
+	Days []string `json:"days" toml:"days" yaml:"days"`
+	// Time of day window, HH:MM, "24:00" is midnight at the end of the day
+	From string `json:"from" toml:"from" yaml:"from"`
+	To   string `json:"to" toml:"to" yaml:"to"`
+}
+
+// PayrollOptions configures the payroll export
+type PayrollOptions struct {
+	Rules []PayRule `json:"rules" toml:"rules" yaml:"rules"`
+	// Minutes past this many hours in an ISO week are overtime, 0 turns it off
+	OvertimeWeeklyHours float64 `json:"overtimeWeeklyHours" toml:"overtimeWeeklyHours" yaml:"overtimeWeeklyHours"`
+	// Salary type codes of the fixed categories
+	RegularCode  string `json:"regularCode" toml:"regularCode" yaml:"regularCode"`
+	OvertimeCode string `json:"overtimeCode" toml:"overtimeCode" yaml:"overtimeCode"`
+	// Totals per week or month
+	Period string `json:"period" toml:"period" yaml:"period"`
+	// Extra holidays on top of the holiday calendar, YYYY-MM-DD
+	Holidays []string `json:"holidays" toml:"holidays" yaml:"holidays"`
+}
+
+func defaultPayrollOptions() PayrollOptions {
+	weekdays := []string{"Monday", "Tuesday", "Wednesday", "Thursday", "Friday"}
+	return PayrollOptions{
+		Rules: []PayRule{
+			{Category: "ob_holiday", Code: "OB3", Days: []string{"holiday"}},
+			{Category: "ob_weekend", Code: "OB2", Days: []string{"Saturday"}, From: "12:00", To: "24:00"},
+			{Category: "ob_weekend", Code: "OB2", Days: []string{"Sunday"}},
+			{Category: "ob_evening", Code: "OB1", Days: weekdays, From: "18:15", To: "24:00"},
+			{Category: "ob_evening", Code: "OB1", Days: weekdays, From: "00:00", To: "06:00"},
+		},
+		OvertimeWeeklyHours: 40,
+		RegularCode:         "ARB",
+		OvertimeCode:        "OT",
+		Period:              PeriodMonth,
+	}
+}
+
+func (p PayrollOptions) validate() error {
+	if p.Period != PeriodWeek && p.Period != PeriodMonth {
+		return fmt.Errorf("period must be %s or %s, got %q", PeriodWeek, PeriodMonth, p.Period)
+	}
+	if p.OvertimeWeeklyHours < 0 {
+		return fmt.Errorf("overtimeWeeklyHours must not be negative")
+	}
+	for _, rule := range p.Rules {
+		if rule.Category == "" || rule.Category == PayRegular || rule.Category == PayOvertime {
+			return fmt.Errorf("rule category %q is empty or reserved", rule.Category)
+		}
+		if _, _, err := rule.window(); err != nil {
+			return fmt.Errorf("rule %s: %v", rule.Category, err)
+		}
+	}
+	for _, date := range p.Holidays {
+		if _, err := time.Parse(time.DateOnly, date); err != nil {
+			return fmt.Errorf("invalid holiday %q", date)
+		}
+	}
+	return nil
+}
+
+// window returns the rule's time of day as minutes after midnight
+func (r PayRule) window() (int, int, error) {
+	from, to := 0, 24*60
+	var err error
+	if r.From != "" {
+		if from, err = minuteOfDay(r.From); err != nil {
+			return 0, 0, err
+		}
+	}
+	if r.To != "" {
+		if to, err = minuteOfDay(r.To); err != nil {
+			return 0, 0, err
+		}
+	}
+	if to <= from {
+		return 0, 0, fmt.Errorf("from %s must be before to %s, split windows past midnight in two rules", r.From, r.To)
+	}
+	return from, to, nil
+}
+
+func minuteOfDay(s string) (int, error) {
+	if s == "24:00" {
+		return 24 * 60, nil
+	}
+	t, err := time.Parse("15:04", s)
+	if err != nil {
+		return 0, fmt.Errorf("invalid time %q", s)
+	}
+	return t.Hour()*60 + t.Minute(), nil
+}
+
+// matches reports whether the minute at t falls inside the rule
+func (r PayRule) matches(t time.Time, holiday bool) bool {
+	if len(r.Days) > 0 && !slices.ContainsFunc(r.Days, func(d string) bool {
+		return strings.EqualFold(d, t.Weekday().String()) || (holiday && strings.EqualFold(d, "holiday"))
+	}) {
+		return false
+	}
+	from, to, _ := r.window()
+	minute := t.Hour()*60 + t.Minute()
+	return minute >= from && minute < to
+}
+
+// categories lists regular, the rule categories in order and overtime
+func (p PayrollOptions) categories() []string {
+	categories := []string{PayRegular}
+	for _, rule := range p.Rules {
+		if !slices.Contains(categories, rule.Category) {
+			categories = append(categories, rule.Category)
+		}
+	}
+	return append(categories, PayOvertime)
+}
+
+// code returns the salary type code of a category
+func (p PayrollOptions) code(category string) string {
+	switch category {
+	case PayRegular:
+		return p.RegularCode
+	case PayOvertime:
+		return p.OvertimeCode
+	}
+	for _, rule := range p.Rules {
+		if rule.Category == category && rule.Code != "" {
+			return rule.Code
+		}
+	}
+	return category
+}
+
+// PayTotal is the paid time of one employee in one period
+type PayTotal struct {
+	Period     string             `json:"period"` // e.g. 2025-W12 or 2025-03
+	PeriodEnd  string             `json:"periodEnd"`
+	EmployeeId int                `json:"employeeId"`
+	Name       string             `json:"name"`
+	Minutes    map[string]int     `json:"minutes"` // by category
+	Hours      map[string]float64 `json:"hours"`
+}
+
+/*
+================================================================================
+Classify every paid minute of the shifts into pay categories
+================================================================================
+*/
+func classifyPay(shifts []Shift, opts ProcessOptions, holidays map[string]bool) ([]PayTotal, error) {
+	totals := map[string]*PayTotal{}
+	err := payMinutes(shifts, opts, holidays, func(shift Shift, start time.Time) func(time.Time, string) {
+		period, periodEnd := payPeriod(start, opts.Payroll.Period)
+		key := period + "/" + strconv.Itoa(shift.EmployeeId)
+		total, ok := totals[key]
+		if !ok {
+			total = &PayTotal{Period: period, PeriodEnd: periodEnd, EmployeeId: shift.EmployeeId, Name: shift.Name(),
+				Minutes: map[string]int{}, Hours: map[string]float64{}}
+			totals[key] = total
+		}
+		return func(_ time.Time, category string) {
+			total.Minutes[category]++
+		}
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	result := []PayTotal{}
+	for _, total := range totals {
+		for category, minutes := range total.Minutes {
+			total.Hours[category] = float64(minutes) / 60
+		}
+		result = append(result, *total)
+	}
+	slices.SortFunc(result, func(a, b PayTotal) int {
+		if c := strings.Compare(a.Period, b.Period); c != 0 {
+			return c
+		}
+		return a.EmployeeId - b.EmployeeId
+	})
+	return result, nil
+}
+
+// payMinutes walks the paid minutes of the shifts in time order. visit is
+// called once per shift and returns the function receiving its minutes.
+func payMinutes(shifts []Shift, opts ProcessOptions, holidays map[string]bool, visit func(shift Shift, start time.Time) func(t time.Time, category string)) error {
+	shifts = slices.Clone(shifts)
+	slices.SortStableFunc(shifts, func(a, b Shift) int {
+		return strings.Compare(a.Date+a.StartTime, b.Date+b.StartTime)
+	})
+	payroll := opts.Payroll
+
+	weekMinutes := map[string]int{} // worked minutes per employee and ISO week, for overtime
+	for _, shift := range shifts {
+		start, err := time.Parse(time.DateOnly+" "+time.TimeOnly, shift.Date+" "+shift.StartTime)
+		if err != nil {
+			return fmt.Errorf("invalid shift start for %d on %s: %v", shift.EmployeeId, shift.Date, err)
+		}
+		end, err := time.Parse(time.DateOnly+" "+time.TimeOnly, shift.Date+" "+shift.EndTime)
+		if err != nil {
+			return fmt.Errorf("invalid shift end for %d on %s: %v", shift.EmployeeId, shift.Date, err)
+		}
+		if !end.After(start) {
+			end = end.AddDate(0, 0, 1)
+		}
+		// Unpaid lunch, placed like on the day sheets
+		lunchStart, lunchEnd := start, start
+		if shift.HasLunch {
+			lunchStart = start.Add(opts.lunchAfter())
+			lunchEnd = lunchStart.Add(time.Duration(opts.LunchHours * float64(time.Hour)))
+		}
+
+		year, week := start.ISOWeek()
+		weekKey := fmt.Sprintf("%d-%d/%d", year, week, shift.EmployeeId)
+		minute := visit(shift, start)
+
+		for t := start; t.Before(end); t = t.Add(time.Minute) {
+			if !t.Before(lunchStart) && t.Before(lunchEnd) {
+				continue
+			}
+			weekMinutes[weekKey]++
+			category := PayRegular
+			if payroll.OvertimeWeeklyHours > 0 && float64(weekMinutes[weekKey]) > payroll.OvertimeWeeklyHours*60 {
+				category = PayOvertime
+			} else {
+				holiday := holidays[t.Format(time.DateOnly)]
+				for _, rule := range payroll.Rules {
+					if rule.matches(t, holiday) {
+						category = rule.Category
+						break
+					}
+				}
+			}
+			minute(t, category)
+		}
+	}
+	return nil
+}
+
+// payPeriod names the period containing t and returns its last date
+func payPeriod(t time.Time, period string) (string, string) {
+	if period == PeriodWeek {
+		year, week := t.ISOWeek()
+		sunday := t.AddDate(0, 0, (7-int(t.Weekday()))%7)
+		return fmt.Sprintf("%d-W%02d", year, week), sunday.Format(time.DateOnly)
+	}
+	last := time.Date(t.Year(), t.Month()+1, 0, 0, 0, 0, 0, time.UTC)
+	return t.Format("2006-01"), last.Format(time.DateOnly)
+}
+
+// payrollHolidays returns the holiday dates the shifts touch, from the
+// holiday calendar plus the payroll's own dates
+func payrollHolidays(opts ProcessOptions, shifts []Shift) map[string]bool {
+	holidays := map[string]bool{}
+	for _, date := range opts.Payroll.Holidays {
+		holidays[date] = true
+	}
+	calendar := NewHolidayCalendar(opts.Holidays)
+	for _, shift := range shifts {
+		day, err := time.Parse(time.DateOnly, shift.Date)
+		if err != nil {
+			continue
+		}
+		// The next day too, for shifts past midnight
+		for _, date := range []string{shift.Date, day.AddDate(0, 0, 1).Format(time.DateOnly)} {
+			if _, ok := calendar.Lookup(date); ok {
+				holidays[date] = true
+			}
+		}
+	}
+	return holidays
+}
+
+/*
+================================================================================
+Payroll files: totals as CSV and PAXml 2.0 salary transactions
+================================================================================
+*/
+func createPayrollExports(shifts []Shift, opts ProcessOptions) (map[string][]byte, error) {
+	totals, err := classifyPay(shifts, opts, payrollHolidays(opts, shifts))
+	if err != nil {
+		return nil, err
+	}
+	categories := opts.Payroll.categories()
+
+	header := append([]string{"period", "employeeId", "name"}, categories...)
+	rows := [][]string{append(header, "total")}
+	for _, total := range totals {
+		row := []string{total.Period, strconv.Itoa(total.EmployeeId), total.Name}
+		sum := 0
+		for _, category := range categories {
+			row = append(row, formatHours(total.Minutes[category]))
+			sum += total.Minutes[category]
+		}
+		rows = append(rows, append(row, formatHours(sum)))
+	}
+	payrollCSV, err := encodeCSV(rows)
+	if err != nil {
+		return nil, err
+	}
+
+	paxml, err := encodePAXml(totals, categories, opts.Payroll)
+	if err != nil {
+		return nil, err
+	}
+	return map[string][]byte{"payroll.csv": payrollCSV, "payroll.paxml.xml": paxml}, nil
+}
+
+func formatHours(minutes int) string {
+	return strconv.FormatFloat(float64(minutes)/60, 'f', 2, 64)
+}
+
+type paxmlFile struct {
+	XMLName      xml.Name        `xml:"paxml"`
+	XSI          string          `xml:"xmlns:xsi,attr"`
+	Schema       string          `xml:"xsi:noNamespaceSchemaLocation,attr"`
+	Format       string          `xml:"header>format"`
+	Version      string          `xml:"header>version"`
+	Transactions []paxmlSalTrans `xml:"lonetransaktioner>lonetrans"`
+}
+
+type paxmlSalTrans struct {
+	EmployeeId  int    `xml:"anstid,attr"`
+	Date        string `xml:"datum"`
+	Code        string `xml:"lonart"`
+	Description string `xml:"benamning"`
+	Quantity    string `xml:"antal"`
+}
+
+func encodePAXml(totals []PayTotal, categories []string, payroll PayrollOptions) ([]byte, error) {
+	doc := paxmlFile{
+		XSI:     "http://www.w3.org/2001/XMLSchema-instance",
+		Schema:  "http://www.paxml.se/2.0/paxml.xsd",
+		Format:  "LÖNIN",
+		Version: "2.0",
+	}
+	for _, total := range totals {
+		for _, category := range categories {
+			if total.Minutes[category] == 0 {
+				continue
+			}
+			doc.Transactions = append(doc.Transactions, paxmlSalTrans{
+				EmployeeId:  total.EmployeeId,
+				Date:        total.PeriodEnd,
+				Code:        payroll.code(category),
+				Description: category + " " + total.Period,
+				Quantity:    formatHours(total.Minutes[category]),
+			})
+		}
+	}
+	var buf bytes.Buffer
+	buf.WriteString(xml.Header)
+	enc := xml.NewEncoder(&buf)
+	enc.Indent("", "  ")
+	if err := enc.Encode(doc); err != nil {
+		return nil, fmt.Errorf("error encoding paxml: %v", err)
+	}
+	buf.WriteString("\n")
+	return buf.Bytes(), nil
+}
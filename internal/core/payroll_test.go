@@ -0,0 +1,85 @@
+package core
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestClassifyPay(t *testing.T) {
+	opts := DefaultProcessOptions()
+	opts.Payroll.Holidays = []string{"2025-03-19"}
+	shifts := []Shift{
+		{EmployeeId: 1, FirstName: "Anna", LastName: "Svensson", Date: "2025-03-17", StartTime: "09:00:00", EndTime: "17:00:00", HasLunch: true},
+		{EmployeeId: 1, FirstName: "Anna", LastName: "Svensson", Date: "2025-03-18", StartTime: "16:00:00", EndTime: "20:00:00"},
+		{EmployeeId: 1, FirstName: "Anna", LastName: "Svensson", Date: "2025-03-19", StartTime: "10:00:00", EndTime: "12:00:00"},
+		{EmployeeId: 1, FirstName: "Anna", LastName: "Svensson", Date: "2025-03-22", StartTime: "10:00:00", EndTime: "14:00:00"},
+		{EmployeeId: 1, FirstName: "Anna", LastName: "Svensson", Date: "2025-03-24", StartTime: "22:00:00", EndTime: "02:00:00"},
+	}
+	totals, err := classifyPay(shifts, opts, payrollHolidays(opts, shifts))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(totals) != 1 || totals[0].Period != "2025-03" || totals[0].PeriodEnd != "2025-03-31" {
+		t.Fatalf("expected one monthly total, got %+v", totals)
+	}
+	want := map[string]int{
+		PayRegular:   7*60 + 135 + 120,
+		"ob_evening": 105 + 4*60, // past midnight into a weekday night
+		"ob_holiday": 120,
+		"ob_weekend": 120,
+	}
+	for category, minutes := range want {
+		if got := totals[0].Minutes[category]; got != minutes {
+			t.Fatalf("expected %d minutes %s, got %d (%v)", minutes, category, got, totals[0].Minutes)
+		}
+	}
+}
+
+func TestClassifyPayOvertime(t *testing.T) {
+	opts := DefaultProcessOptions()
+	opts.Payroll.OvertimeWeeklyHours = 8
+	opts.Payroll.Period = PeriodWeek
+	shifts := []Shift{
+		{EmployeeId: 2, Date: "2025-03-17", StartTime: "09:00:00", EndTime: "17:00:00"},
+		{EmployeeId: 2, Date: "2025-03-18", StartTime: "09:00:00", EndTime: "11:00:00"},
+		{EmployeeId: 2, Date: "2025-03-24", StartTime: "09:00:00", EndTime: "11:00:00"},
+	}
+	totals, err := classifyPay(shifts, opts, nil)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(totals) != 2 || totals[0].Period != "2025-W12" || totals[0].PeriodEnd != "2025-03-23" {
+		t.Fatalf("expected two weekly totals, got %+v", totals)
+	}
+	if totals[0].Minutes[PayRegular] != 480 || totals[0].Minutes[PayOvertime] != 120 {
+		t.Fatalf("unexpected week 12 minutes %v", totals[0].Minutes)
+	}
+	if totals[1].Minutes[PayOvertime] != 0 {
+		t.Fatalf("overtime must restart each week, got %v", totals[1].Minutes)
+	}
+}
+
+func TestProcessFilesPayroll(t *testing.T) {
+	opts := DefaultProcessOptions()
+	opts.Formats = []string{"payroll"}
+	result, err := ProcessFilesWithOptions(buildInputFile(t, employeeShifts), nil, nil, opts)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	payroll := strings.TrimPrefix(string(result["payroll.csv"]), "\xef\xbb\xbf")
+	if !strings.HasPrefix(payroll, "period,employeeId,name,regular,ob_holiday,ob_weekend,ob_evening,overtime,total\n") ||
+		!strings.Contains(payroll, "2025-03,1,Anna Svensson,11.00,0.00,0.00,0.00,0.00,11.00\n") {
+		t.Fatalf("unexpected payroll.csv:\n%s", payroll)
+	}
+	paxml := string(result["payroll.paxml.xml"])
+	for _, want := range []string{"<format>LÖNIN</format>", `<lonetrans anstid="2">`, "<lonart>ARB</lonart>", "<antal>4.00</antal>", "<datum>2025-03-31</datum>"} {
+		if !strings.Contains(paxml, want) {
+			t.Fatalf("expected %q in paxml:\n%s", want, paxml)
+		}
+	}
+
+	opts.Payroll.Rules = []PayRule{{Category: "night", From: "22:00", To: "06:00"}}
+	if err := opts.Validate(); err == nil {
+		t.Fatalf("expected error for a window past midnight")
+	}
+}
@@ -0,0 +1,295 @@
+package core
+
+import (
+	"bytes"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/go-pdf/fpdf"
+)
+
+// Paper names understood by fpdf
+var pdfPaperSizes = map[string]string{
+	"letter": "Letter",
+	"legal":  "Legal",
+	"a3":     "A3",
+	"a4":     "A4",
+	"a5":     "A5",
+}
+
+// Relative column widths, as in the xlsx output
+const (
+	pdfTimeWidth  = 15.0
+	pdfNameWidth  = 30.0
+	pdfPhoneWidth = 12.0
+	pdfHourWidth  = 12.0
+	pdfTotalWidth = 8.0
+)
+
+/*
+================================================================================
+Render the days of a week as PDF, one page per day plus a combined file
+================================================================================
+*/
+func createWeekPDFs(files map[string][]byte, name string, views []dayView, rc renderContext) error {
+	for _, view := range views {
+		buf, err := renderPDF([]dayView{view}, rc)
+		if err != nil {
+			return err
+		}
+		files[name+" - "+view.data.dayStr+".pdf"] = buf
+	}
+	buf, err := renderPDF(views, rc)
+	if err != nil {
+		return err
+	}
+	files[name+".pdf"] = buf
+	return nil
+}
+
+// renderPDF writes one page per day into a single document
+func renderPDF(views []dayView, rc renderContext) ([]byte, error) {
+	page := rc.opts.Page
+	orientation := "L"
+	if page.Orientation == "portrait" {
+		orientation = "P"
+	}
+	pdf := fpdf.New(orientation, "mm", pdfPaperSizes[strings.ToLower(page.PaperSize)], "")
+	m := page.Margins
+	pdf.SetMargins(m.Left*10, m.Top*10, m.Right*10)
+	pdf.SetAutoPageBreak(false, m.Bottom*10)
+	// Same bytes for the same input
+	if len(views) > 0 {
+		created, _ := time.Parse(time.DateOnly, views[0].data.dateStr)
+		pdf.SetCreationDate(created)
+		pdf.SetModificationDate(created)
+		pdf.SetTitle(views[0].data.weekStr, true)
+	}
+
+	w := &pdfWriter{pdf: pdf, tr: pdf.UnicodeTranslatorFromDescriptor(""), theme: rc.theme}
+	for _, view := range views {
+		w.day(view)
+	}
+
+	var buf bytes.Buffer
+	if err := pdf.Output(&buf); err != nil {
+		return nil, fmt.Errorf("error writing pdf: %v", err)
+	}
+	return buf.Bytes(), nil
+}
+
+// pdfWriter draws the day pages, text is translated to the core font encoding
+type pdfWriter struct {
+	pdf   *fpdf.Fpdf
+	tr    func(string) string
+	theme ThemeOptions
+}
+
+func (w *pdfWriter) day(view dayView) {
+	pdf := w.pdf
+	pdf.AddPage()
+	pageWidth, pageHeight := pdf.GetPageSize()
+	left, top, right, bottom := pdf.GetMargins()
+	width := pageWidth - left - right
+	data := view.data
+
+	headerLines := view.header.Lines(view.values)
+	footerLines := view.footer.Lines(view.values)
+
+	// Column widths scaled to the page width
+	hours := len(data.headers) - 3
+	widths := []float64{pdfTimeWidth, pdfNameWidth, pdfPhoneWidth}
+	for range hours {
+		widths = append(widths, pdfHourWidth)
+	}
+	widths = append(widths, pdfTotalWidth)
+	total := 0.0
+	for _, cw := range widths {
+		total += cw
+	}
+	for i := range widths {
+		widths[i] *= width / total
+	}
+
+	// Shrink the rows when the day has many shifts
+	const lineHeight = 5.0
+	fixed := 12.0 + float64(len(headerLines)+len(footerLines))*lineHeight
+	if w.theme.Legend {
+		fixed += 2 * lineHeight
+	}
+	if len(view.notes) > 0 {
+		fixed += float64(len(view.notes)+2) * lineHeight
+	}
+	rowHeight := (pageHeight - top - bottom - fixed) / float64(len(data.shifts)+3)
+	rowHeight = min(max(rowHeight, 3.5), 7)
+	fontSize := min(rowHeight*1.5, 10)
+
+	// Header block
+	w.lines(headerLines, width, lineHeight)
+	if len(headerLines) > 0 {
+		pdf.Ln(lineHeight)
+	}
+
+	// Title
+	title := w.theme.Title
+	if view.holiday != nil {
+		title = w.theme.Holiday
+	}
+	w.style(title, title.FontSize*0.75)
+	pdf.CellFormat(width, 12, w.tr(view.title()), w.border(title), 1, "C", w.fill(title), 0, "")
+
+	// Header row, shift rows and the trailing header
+	w.headerRow(data.headers, widths, rowHeight, fontSize)
+	for _, shift := range data.shifts {
+		texts := []string{shift.shiftTime, shift.employeeName, shift.phone}
+		for i, text := range texts {
+			def := w.theme.Work
+			if i == 1 {
+				def = w.theme.Name
+			}
+			w.style(def, fontSize)
+			pdf.CellFormat(widths[i], rowHeight, w.tr(text), w.border(def), 0, "L", i == 1 && w.fill(def), 0, "")
+		}
+		for hourIdx := 0; hourIdx < hours; hourIdx++ {
+			state := shift.hourSchedule[hourIdx]
+			def := w.activity(state, shift.role)
+			text := ""
+			switch state {
+			case StateLunch:
+				text = "Lunch"
+			case StateAssigned:
+				text = shift.role
+			}
+			w.style(def, fontSize)
+			pdf.CellFormat(widths[3+hourIdx], rowHeight, w.tr(text), w.border(def), 0, "C", w.fill(def), 0, "")
+		}
+		w.style(StyleDef{}, fontSize)
+		pdf.CellFormat(widths[len(widths)-1], rowHeight, shift.shiftLength, "", 1, "C", false, 0, "")
+	}
+	w.headerRow(data.headers, widths, rowHeight, fontSize)
+	pdf.Ln(lineHeight)
+
+	// Legend
+	if w.theme.Legend {
+		entries := []StyleDef{w.theme.Work, w.theme.Lunch, w.theme.Free, w.theme.Assigned}
+		entries = append(entries, w.theme.roleDefs()...)
+		entryWidth := min(width/float64(len(entries)+1), 30)
+		w.style(w.theme.Header, fontSize)
+		pdf.CellFormat(entryWidth, lineHeight, w.tr(w.theme.LegendTitle), w.border(w.theme.Header), 0, "C", w.fill(w.theme.Header), 0, "")
+		for _, def := range entries {
+			w.style(def, fontSize)
+			pdf.CellFormat(entryWidth, lineHeight, w.tr(def.Label), w.border(def), 0, "C", w.fill(def), 0, "")
+		}
+		pdf.Ln(2 * lineHeight)
+	}
+
+	// Notes
+	if len(view.notes) > 0 {
+		w.style(w.theme.Header, fontSize)
+		pdf.CellFormat(width, lineHeight, w.tr(w.theme.NotesTitle), w.border(w.theme.Header), 1, "L", w.fill(w.theme.Header), 0, "")
+		for _, note := range view.notes {
+			def := w.theme.Note
+			if note.Priority == PriorityHigh {
+				def = w.theme.NoteHigh
+			}
+			text := note.Text
+			if note.Department != "" {
+				text = note.Department + ": " + text
+			}
+			w.style(def, fontSize)
+			pdf.MultiCell(width, lineHeight, w.tr(text), w.border(def), "L", w.fill(def))
+		}
+		pdf.Ln(lineHeight)
+	}
+
+	// Footer text
+	w.lines(footerLines, width, lineHeight)
+}
+
+func (w *pdfWriter) headerRow(headers []string, widths []float64, height float64, fontSize float64) {
+	def := w.theme.Header
+	w.style(def, fontSize)
+	for i, header := range headers {
+		w.pdf.CellFormat(widths[i], height, w.tr(header), w.border(def), 0, "C", w.fill(def), 0, "")
+	}
+	w.pdf.Ln(height)
+}
+
+func (w *pdfWriter) lines(lines []string, width float64, height float64) {
+	w.style(StyleDef{}, 10)
+	for _, line := range lines {
+		w.pdf.MultiCell(width, height, w.tr(line), "", "L", false)
+	}
+}
+
+func (w *pdfWriter) activity(state HourActivity, role string) StyleDef {
+	switch state {
+	case StateWork:
+		return w.theme.Work
+	case StateLunch:
+		return w.theme.Lunch
+	case StateAssigned:
+		if def, ok := w.theme.Roles[role]; ok {
+			return def
+		}
+		return w.theme.Assigned
+	}
+	return w.theme.Free
+}
+
+// style sets font, colours and line width of the next cells
+func (w *pdfWriter) style(def StyleDef, size float64) {
+	fontStyle := ""
+	if def.Bold {
+		fontStyle += "B"
+	}
+	if def.Italic {
+		fontStyle += "I"
+	}
+	if size <= 0 {
+		size = 10
+	}
+	w.pdf.SetFont("Helvetica", fontStyle, size)
+	w.pdf.SetTextColor(hexColor(def.FontColor, 0))
+	r, g, b := hexColor(def.Fill, 255)
+	// Fill patterns are drawn as a light tint of the pattern colour
+	if def.Pattern > 1 {
+		r, g, b = tint(r), tint(g), tint(b)
+	}
+	w.pdf.SetFillColor(r, g, b)
+	w.pdf.SetDrawColor(hexColor(def.BorderColor, 0))
+	switch def.Border {
+	case "medium":
+		w.pdf.SetLineWidth(0.4)
+	case "thick":
+		w.pdf.SetLineWidth(0.6)
+	default:
+		w.pdf.SetLineWidth(0.2)
+	}
+}
+
+func (w *pdfWriter) fill(def StyleDef) bool {
+	return def.Fill != "" && def.Pattern != 0
+}
+
+func (w *pdfWriter) border(def StyleDef) string {
+	if def.Border == "" || def.Border == "none" {
+		return ""
+	}
+	return "1"
+}
+
+// hexColor parses "#RRGGBB" or "RRGGBB", empty or invalid gives the fallback grey level
+func hexColor(s string, fallback int) (int, int, int) {
+	v, err := strconv.ParseUint(strings.TrimPrefix(s, "#"), 16, 32)
+	if err != nil || len(strings.TrimPrefix(s, "#")) != 6 {
+		return fallback, fallback, fallback
+	}
+	return int(v >> 16 & 0xFF), int(v >> 8 & 0xFF), int(v & 0xFF)
+}
+
+func tint(c int) int {
+	return 255 - (255-c)/4
+}
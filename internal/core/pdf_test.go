@@ -0,0 +1,63 @@
+package core
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/xuri/excelize/v2"
+)
+
+func TestProcessFilesPDF(t *testing.T) {
+	src := excelize.NewFile()
+	src.SetSheetName("Sheet1", "Footer")
+	src.SetCellValue("Footer", "A1", "Stängning {{weekday}}")
+	footerBuf, _ := src.WriteToBuffer()
+
+	input := buildInputFile(t, [][]string{
+		{"1", "Svensson", "Anna", "Pass", "2025-03-17", "09:00 - 17:00", "Kassa"},
+		{"2", "Berg", "Erik", "Pass", "2025-03-18", "12:00 - 16:00", "Kassa"},
+	})
+	opts := DefaultProcessOptions()
+	opts.Formats = []string{"xlsx", "PDF"}
+	opts.Theme.Legend = true
+	result, err := ProcessFilesWithOptions(input, nil, bytes.NewReader(footerBuf.Bytes()), opts)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	for _, name := range []string{"Vecka 12.xlsx", "Vecka 12.pdf", "Vecka 12 - Monday.pdf", "Vecka 12 - Tuesday.pdf"} {
+		if len(result[name]) == 0 {
+			t.Fatalf("expected %s in result, got %d files", name, len(result))
+		}
+	}
+	week := string(result["Vecka 12.pdf"])
+	if !strings.HasPrefix(week, "%PDF-") || strings.Count(week, "/Type /Page\n") != 2 {
+		t.Fatalf("expected a two page pdf")
+	}
+	if strings.Count(string(result["Vecka 12 - Monday.pdf"]), "/Type /Page\n") != 1 {
+		t.Fatalf("expected a single page per day")
+	}
+}
+
+func TestFooterLines(t *testing.T) {
+	footer := Footer{Cells: []FooterCell{
+		{Row: 1, Col: 2, Value: "{{date}}"},
+		{Row: 1, Col: 1, Value: "Datum"},
+		{Row: 3, Col: 1, RichText: []excelize.RichTextRun{{Text: "Rich "}, {Text: "text"}}},
+		{Row: 4, Col: 1, Value: ""},
+	}}
+	lines := footer.Lines(map[string]string{"date": "2025-03-17"})
+	want := []string{"Datum  2025-03-17", "", "Rich text"}
+	if strings.Join(lines, "|") != strings.Join(want, "|") {
+		t.Fatalf("Lines() = %q, want %q", lines, want)
+	}
+}
+
+func TestHexColor(t *testing.T) {
+	if r, g, b := hexColor("#A1C2F1", 0); r != 0xA1 || g != 0xC2 || b != 0xF1 {
+		t.Fatalf("unexpected colour %d %d %d", r, g, b)
+	}
+	if r, _, _ := hexColor("", 255); r != 255 {
+		t.Fatalf("expected fallback for empty colour")
+	}
+}
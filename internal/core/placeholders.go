@@ -0,0 +1,94 @@
+package core
+
+import (
+	"errors"
+	"io"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/go-gota/gota/dataframe"
+)
+
+// Placeholders like {{week}} or {{closingManager}}, names are case-insensitive
+var placeholderPattern = regexp.MustCompile(`\{\{\s*([A-Za-z0-9_\-]+)\s*\}\}`)
+
+// replacePlaceholders substitutes known placeholders, unknown ones are kept
+func replacePlaceholders(s string, values map[string]string) string {
+	if len(values) == 0 || !strings.Contains(s, "{{") {
+		return s
+	}
+	return placeholderPattern.ReplaceAllStringFunc(s, func(match string) string {
+		name := placeholderPattern.FindStringSubmatch(match)[1]
+		if v, ok := values[strings.ToLower(name)]; ok {
+			return v
+		}
+		return match
+	})
+}
+
+// readDayValues reads per-date placeholder values from a table with a
+// "date" column and one column per placeholder name
+func readDayValues(r io.Reader) (map[string]map[string]string, error) {
+	rows, err := readTable(r)
+	if err != nil {
+		return nil, errors.New("Error reading day values: " + err.Error())
+	}
+	values := map[string]map[string]string{}
+	for _, record := range tableRecords(rows) {
+		date, err := normalizeDate(record["date"])
+		if err != nil {
+			return nil, errors.New("Error reading day values: " + err.Error())
+		}
+		if values[date] == nil {
+			values[date] = map[string]string{}
+		}
+		for key, v := range record {
+			if key != "date" {
+				values[date][key] = v
+			}
+		}
+	}
+	return values, nil
+}
+
+// dayPlaceholderValues builds the values available on one day sheet
+func dayPlaceholderValues(dayData DaySchedule, dateDf dataframe.DataFrame, extra map[string]string) map[string]string {
+	employees := map[int]bool{}
+	totalHours := 0.0
+	for _, shift := range dayData.shifts {
+		if shift.absentAll {
+			continue
+		}
+		employees[shift.employeeId] = true
+		hours, _ := strconv.ParseFloat(shift.shiftLength, 64)
+		totalHours += hours
+	}
+	departments := []string{}
+	for _, d := range dateDf.Col("department").Records() {
+		if d != "" && !slices.Contains(departments, d) {
+			departments = append(departments, d)
+		}
+	}
+	slices.Sort(departments)
+
+	year := ""
+	if len(dayData.dateStr) >= 4 {
+		year = dayData.dateStr[:4]
+	}
+	values := map[string]string{
+		"date":       dayData.dateStr,
+		"weekday":    dayData.dayStr,
+		"week":       dayData.weekStr,
+		"year":       year,
+		"department": strings.Join(departments, ", "),
+		"headcount":  strconv.Itoa(len(employees)),
+		"totalhours": strconv.FormatFloat(totalHours, 'f', -1, 64),
+	}
+	// Values from the day values file win over the computed ones
+	for k, v := range extra {
+		values[k] = v
+	}
+	return values
+}
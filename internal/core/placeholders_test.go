@@ -0,0 +1,80 @@
+package core
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/xuri/excelize/v2"
+)
+
+func TestReplacePlaceholders(t *testing.T) {
+	values := map[string]string{"week": "12", "closingmanager": "Anna"}
+	got := replacePlaceholders("Week {{week}} closing manager: {{ closingManager }} {{unknown}}", values)
+	if got != "Week 12 closing manager: Anna {{unknown}}" {
+		t.Fatalf("unexpected result: %q", got)
+	}
+}
+
+func TestNormalizeDate(t *testing.T) {
+	for _, in := range []string{"2025-03-17", "2025/03/17", "03-17-25", "17.03.2025", "45733"} {
+		got, err := normalizeDate(in)
+		if err != nil || got != "2025-03-17" {
+			t.Fatalf("normalizeDate(%q) = %q, %v", in, got, err)
+		}
+	}
+	if _, err := normalizeDate("someday"); err == nil {
+		t.Fatalf("expected error for invalid date")
+	}
+}
+
+func TestReadDayValuesCSV(t *testing.T) {
+	csv := "\xef\xbb\xbfDate;ClosingManager;Delivery\n2025-03-17;Anna;Bröd 07:00\n\n"
+	values, err := readDayValues(strings.NewReader(csv))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if values["2025-03-17"]["closingmanager"] != "Anna" || values["2025-03-17"]["delivery"] != "Bröd 07:00" {
+		t.Fatalf("unexpected values: %v", values)
+	}
+}
+
+func TestProcessFilesFooterAndHeaderPlaceholders(t *testing.T) {
+	src := excelize.NewFile()
+	src.SetSheetName("Sheet1", "Footer")
+	src.SetCellValue("Footer", "A1", "{{weekday}} week {{week}}: {{headcount}} staff, {{totalHours}} h")
+	src.SetCellValue("Footer", "A2", "Closing manager: {{closingManager}}")
+	src.NewSheet("Header")
+	src.SetCellValue("Header", "A1", "Store 42 - {{date}}")
+	footerBuf, _ := src.WriteToBuffer()
+
+	input := buildInputFile(t, [][]string{
+		{"1", "Svensson", "Anna", "Pass", "2025-03-17", "09:00 - 17:00", "Kassa"},
+		{"2", "Berg", "Erik", "Pass", "2025-03-17", "12:00 - 16:00", "Kassa"},
+	})
+	opts := DefaultProcessOptions()
+	opts.DayValues = strings.NewReader("date,closingManager\n2025-03-17,Erik\n")
+	result, err := ProcessFilesWithOptions(input, nil, bytes.NewReader(footerBuf.Bytes()), opts)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	f, err := excelize.OpenReader(bytes.NewReader(result["Vecka 12.xlsx"]))
+	if err != nil {
+		t.Fatalf("opening result: %v", err)
+	}
+	defer f.Close()
+
+	// Header block in row 1, blank row, title moved to row 3
+	expect := map[string]string{
+		"A1":  "Store 42 - 2025-03-17",
+		"A3":  "Monday - 2025-03-17",
+		"B5":  "Anna Svensson",
+		"A9":  "Monday week 12: 2 staff, 11 h",
+		"A10": "Closing manager: Erik",
+	}
+	for cell, want := range expect {
+		if got, _ := f.GetCellValue("Monday", cell); got != want {
+			t.Fatalf("expected %q in %s, got %q", want, cell, got)
+		}
+	}
+}
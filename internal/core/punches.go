@@ -0,0 +1,543 @@
+package core
+
+import (
+	"cmp"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/go-gota/gota/dataframe"
+	"github.com/xuri/excelize/v2"
+)
+
+// TimeClockOptions configures the comparison of planned shifts with time-clock punches
+type TimeClockOptions struct {
+	// Minutes of deviation that are not reported
+	Tolerance int `json:"tolerance" toml:"tolerance" yaml:"tolerance"`
+	// Appended to the week file name, e.g. "Vecka 12 - Utfall.xlsx"
+	FileSuffix string `json:"fileSuffix" toml:"fileSuffix" yaml:"fileSuffix"`
+	// Sheet with a row per shift and the sheet with totals per employee
+	Sheet        string `json:"sheet" toml:"sheet" yaml:"sheet"`
+	SummarySheet string `json:"summarySheet" toml:"summarySheet" yaml:"summarySheet"`
+	// Column headers of the sheets
+	PunchedHeader string `json:"punchedHeader" toml:"punchedHeader" yaml:"punchedHeader"`
+	StatusHeader  string `json:"statusHeader" toml:"statusHeader" yaml:"statusHeader"`
+	PlannedHeader string `json:"plannedHeader" toml:"plannedHeader" yaml:"plannedHeader"`
+	DiffHeader    string `json:"diffHeader" toml:"diffHeader" yaml:"diffHeader"`
+}
+
+func defaultTimeClockOptions() TimeClockOptions {
+	return TimeClockOptions{
+		Tolerance:    5,
+		FileSuffix:   "Utfall",
+		Sheet:        "Utfall",
+		SummarySheet: "Avvikelser",
+
+		PunchedHeader: "Stämplat",
+		StatusHeader:  "Status",
+		PlannedHeader: "Planerat",
+		DiffHeader:    "Differens",
+	}
+}
+
+func (t TimeClockOptions) validate() error {
+	if t.Tolerance < 0 {
+		return errors.New("tolerance must not be negative")
+	}
+	if t.FileSuffix == "" || t.Sheet == "" || t.SummarySheet == "" || t.Sheet == t.SummarySheet {
+		return errors.New("fileSuffix, sheet and summarySheet must be set and the sheets differ")
+	}
+	return nil
+}
+
+// Punch is one clock-in and clock-out, either may be missing
+type Punch struct {
+	EmployeeId int
+	Date       string // YYYY-MM-DD of the clock-in
+	In         string // HH:MM, empty when missing
+	Out        string
+}
+
+// punchMinute accepts HH:MM or HH:MM:SS
+func punchMinute(s string) (int, error) {
+	if t, err := time.Parse(time.TimeOnly, s); err == nil {
+		return t.Hour()*60 + t.Minute(), nil
+	}
+	return minuteOfDay(s)
+}
+
+/*
+================================================================================
+Read the time-clock file: employeeId, date, clock-in and clock-out
+================================================================================
+*/
+func readPunches(r io.Reader) ([]Punch, error) {
+	rows, err := readTable(r)
+	if err != nil {
+		return nil, errors.New("Error reading punches: " + err.Error())
+	}
+	punches := []Punch{}
+	for i, record := range tableRecords(rows) {
+		id, err := strconv.Atoi(record["employeeid"])
+		if err != nil {
+			return nil, fmt.Errorf("Error reading punches row %d: invalid employeeId %q", i+2, record["employeeid"])
+		}
+		date, err := normalizeDate(record["date"])
+		if err != nil {
+			return nil, fmt.Errorf("Error reading punches row %d: %v", i+2, err)
+		}
+		punch := Punch{
+			EmployeeId: id,
+			Date:       date,
+			In:         cmp.Or(record["clock-in"], record["clockin"], record["in"]),
+			Out:        cmp.Or(record["clock-out"], record["clockout"], record["out"]),
+		}
+		// Kept as HH:MM for display
+		for _, s := range []*string{&punch.In, &punch.Out} {
+			if *s == "" {
+				continue
+			}
+			minute, err := punchMinute(*s)
+			if err != nil {
+				return nil, fmt.Errorf("Error reading punches row %d: %v", i+2, err)
+			}
+			*s = fmt.Sprintf("%02d:%02d", minute/60, minute%60)
+		}
+		punches = append(punches, punch)
+	}
+	return punches, nil
+}
+
+// punchRow compares one planned shift with its punches, either may be missing
+type punchRow struct {
+	date       string
+	employeeId int
+	name       string
+	planned    *Shift
+	punch      *Punch
+	// Minutes of the day, ends past midnight above 24h
+	plannedStart, plannedEnd int
+	actualStart, actualEnd   int
+	lunchStart, lunchEnd     int
+	// Deviations in minutes beyond the tolerance
+	late, early, extra int
+	missing            bool
+}
+
+// span returns the minutes of the day covered by the row
+func (r punchRow) span() (int, int) {
+	switch {
+	case r.planned == nil && r.missing:
+		return 24 * 60, 0
+	case r.planned == nil:
+		return r.actualStart, r.actualEnd
+	case r.missing:
+		return r.plannedStart, r.plannedEnd
+	}
+	return min(r.plannedStart, r.actualStart), max(r.plannedEnd, r.actualEnd)
+}
+
+// plannedHours and actualHours leave out the planned lunch
+func (r punchRow) plannedHours() float64 {
+	if r.planned == nil {
+		return 0
+	}
+	return r.planned.ShiftLength
+}
+
+func (r punchRow) actualHours() float64 {
+	if r.missing {
+		return 0
+	}
+	return float64(r.actualEnd-r.actualStart-(r.lunchEnd-r.lunchStart)) / 60
+}
+
+// slotStyle returns the style of the hour starting at minute start
+func (r punchRow) slotStyle(start int, styles sheetStyles) int {
+	overlaps := func(from, to int) bool { return from < start+60 && start < to }
+	planned := r.planned != nil && overlaps(r.plannedStart, r.plannedEnd)
+	actual := !r.missing && overlaps(r.actualStart, r.actualEnd)
+	switch {
+	case planned && r.missing:
+		return styles.missing
+	case planned && r.late > 0 && overlaps(r.plannedStart, r.actualStart):
+		return styles.late
+	case planned && r.early > 0 && overlaps(r.actualEnd, r.plannedEnd):
+		return styles.early
+	case actual && r.planned == nil,
+		actual && r.extra > 0 && (overlaps(r.actualStart, r.plannedStart) || overlaps(r.plannedEnd, r.actualEnd)):
+		return styles.extra
+	case planned && overlaps(r.lunchStart, r.lunchEnd) && start >= r.lunchStart:
+		return styles.lunch
+	case planned || actual:
+		return styles.work
+	}
+	return styles.free
+}
+
+// compare fills in the actual times and deviations of the row
+func (r *punchRow) compare(opts ProcessOptions) {
+	r.missing = r.punch == nil || r.punch.In == "" || r.punch.Out == ""
+	if r.planned != nil {
+		r.plannedStart, _ = punchMinute(r.planned.StartTime)
+		r.plannedEnd, _ = punchMinute(r.planned.EndTime)
+		if r.plannedEnd <= r.plannedStart {
+			r.plannedEnd += 24 * 60
+		}
+		if r.planned.HasLunch {
+			r.lunchStart = r.plannedStart + int(opts.lunchAfter().Minutes())
+			r.lunchEnd = r.lunchStart + int(opts.LunchHours*60)
+		}
+	}
+	if r.missing {
+		return
+	}
+	r.actualStart, _ = punchMinute(r.punch.In)
+	r.actualEnd, _ = punchMinute(r.punch.Out)
+	if r.actualEnd <= r.actualStart {
+		r.actualEnd += 24 * 60
+	}
+	beyond := func(minutes int) int {
+		if minutes > opts.TimeClock.Tolerance {
+			return minutes
+		}
+		return 0
+	}
+	if r.planned == nil {
+		r.extra = r.actualEnd - r.actualStart
+		return
+	}
+	r.late = beyond(r.actualStart - r.plannedStart)
+	r.early = beyond(r.plannedEnd - r.actualEnd)
+	r.extra = beyond(r.plannedStart-r.actualStart) + beyond(r.actualEnd-r.plannedEnd)
+}
+
+// status names the deviations of the row
+func (r punchRow) status(theme ThemeOptions) string {
+	labels := []string{}
+	if r.missing {
+		labels = append(labels, theme.Missing.Label)
+	}
+	if r.late > 0 {
+		labels = append(labels, theme.Late.Label)
+	}
+	if r.early > 0 {
+		labels = append(labels, theme.Early.Label)
+	}
+	if r.extra > 0 {
+		labels = append(labels, theme.Extra.Label)
+	}
+	return strings.Join(labels, ", ")
+}
+
+/*
+================================================================================
+Pair the shifts of a week with the punches of the same employee and date, each
+punch with the shift it overlaps most. Punches without a shift are unplanned
+work.
+================================================================================
+*/
+func comparePunches(shifts []Shift, punches []Punch, names map[int]string, opts ProcessOptions) []punchRow {
+	type key struct {
+		id   int
+		date string
+	}
+	planned, punched := map[key][]int{}, map[key][]int{}
+	keys := []key{}
+	for i, s := range shifts {
+		k := key{s.EmployeeId, s.Date}
+		if _, ok := planned[k]; !ok && punched[k] == nil {
+			keys = append(keys, k)
+		}
+		planned[k] = append(planned[k], i)
+	}
+	for i, p := range punches {
+		k := key{p.EmployeeId, p.Date}
+		if _, ok := punched[k]; !ok && planned[k] == nil {
+			keys = append(keys, k)
+		}
+		punched[k] = append(punched[k], i)
+	}
+
+	rows := []punchRow{}
+	for _, k := range keys {
+		slices.SortStableFunc(planned[k], func(a, b int) int { return strings.Compare(shifts[a].StartTime, shifts[b].StartTime) })
+		slices.SortStableFunc(punched[k], func(a, b int) int { return strings.Compare(punches[a].In, punches[b].In) })
+
+		// Largest overlaps first, a punch touching no shift stays unplanned
+		type candidate struct{ shift, punch, minutes int }
+		candidates := []candidate{}
+		for _, s := range planned[k] {
+			for _, p := range punched[k] {
+				if minutes := punchOverlap(shifts[s], punches[p]); minutes > 0 {
+					candidates = append(candidates, candidate{s, p, minutes})
+				}
+			}
+		}
+		slices.SortStableFunc(candidates, func(a, b candidate) int { return b.minutes - a.minutes })
+		pairedShift, pairedPunch := map[int]int{}, map[int]bool{}
+		for _, c := range candidates {
+			if _, ok := pairedShift[c.shift]; !ok && !pairedPunch[c.punch] {
+				pairedShift[c.shift], pairedPunch[c.punch] = c.punch, true
+			}
+		}
+
+		keyRows := []punchRow{}
+		newRow := func() punchRow {
+			return punchRow{date: k.date, employeeId: k.id, name: cmp.Or(names[k.id], "Id "+strconv.Itoa(k.id))}
+		}
+		for _, s := range planned[k] {
+			row := newRow()
+			row.planned = &shifts[s]
+			row.name = row.planned.Name()
+			if p, ok := pairedShift[s]; ok {
+				row.punch = &punches[p]
+			}
+			row.compare(opts)
+			keyRows = append(keyRows, row)
+		}
+		for _, p := range punched[k] {
+			if !pairedPunch[p] {
+				row := newRow()
+				row.punch = &punches[p]
+				row.compare(opts)
+				keyRows = append(keyRows, row)
+			}
+		}
+		slices.SortStableFunc(keyRows, func(a, b punchRow) int {
+			startA, _ := a.span()
+			startB, _ := b.span()
+			return startA - startB
+		})
+		rows = append(rows, keyRows...)
+	}
+	slices.SortStableFunc(rows, func(a, b punchRow) int {
+		return cmp.Or(strings.Compare(a.date, b.date), strings.Compare(a.name, b.name), a.employeeId-b.employeeId)
+	})
+	return rows
+}
+
+// punchOverlap returns the minutes a punch shares with a shift, a punch missing
+// its clock-in or clock-out counts as the minute it has
+func punchOverlap(s Shift, p Punch) int {
+	start, _ := punchMinute(s.StartTime)
+	end, _ := punchMinute(s.EndTime)
+	if end <= start {
+		end += 24 * 60
+	}
+	in, errIn := punchMinute(p.In)
+	out, errOut := punchMinute(p.Out)
+	switch {
+	case errIn != nil && errOut != nil:
+		return 0
+	case errIn != nil:
+		in = out - 1
+	case errOut != nil:
+		out = in + 1
+	case out <= in:
+		out += 24 * 60
+	}
+	return min(end, out) - max(start, in)
+}
+
+// createComparisonWorkbook renders planned against actual for one week, a
+// row per shift on the first sheet and totals per employee on the second
+func createComparisonWorkbook(weekDf dataframe.DataFrame, title string, rc renderContext) ([]byte, error) {
+	shifts, err := shiftsFromDataFrame(weekDf)
+	if err != nil {
+		return nil, err
+	}
+	monday, err := time.Parse(time.DateOnly, slices.Min(weekDf.Col("date").Records()))
+	if err != nil {
+		return nil, errors.New("Error parsing date: " + err.Error())
+	}
+	monday = monday.AddDate(0, 0, -(int(monday.Weekday())+6)%7)
+	from, to := monday.Format(time.DateOnly), monday.AddDate(0, 0, 6).Format(time.DateOnly)
+	punches := []Punch{}
+	for _, p := range rc.punches {
+		if from <= p.Date && p.Date <= to {
+			punches = append(punches, p)
+		}
+	}
+	rows := comparePunches(shifts, punches, rc.names, rc.opts)
+
+	f := excelize.NewFile()
+	defer f.Close()
+	rc.styles, err = setStyles(f, rc.theme)
+	if err != nil {
+		return nil, err
+	}
+	opts := rc.opts.TimeClock
+	if err := f.SetSheetName("Sheet1", opts.Sheet); err != nil {
+		return nil, err
+	}
+	if err := writeComparisonSheet(f, opts.Sheet, rows, title+" - "+opts.FileSuffix, rc); err != nil {
+		return nil, err
+	}
+	if _, err := f.NewSheet(opts.SummarySheet); err != nil {
+		return nil, err
+	}
+	if err := writeDeviationSheet(f, opts.SummarySheet, rows, title+" - "+opts.SummarySheet, rc); err != nil {
+		return nil, err
+	}
+	buf, err := f.WriteToBuffer()
+	if err != nil {
+		return nil, fmt.Errorf("error writing to buffer: %v", err)
+	}
+	return buf.Bytes(), nil
+}
+
+// writeComparisonSheet writes a row per shift with the hour grid between the
+// times and the deviations
+func writeComparisonSheet(f *excelize.File, sheet string, rows []punchRow, title string, rc renderContext) error {
+	first, last := 24*60, 0
+	for _, r := range rows {
+		start, end := r.span()
+		first, last = min(first, start), max(last, end)
+	}
+	slots := []int{}
+	for t := first / 60 * 60; t < last; t += 60 {
+		slots = append(slots, t)
+	}
+	headers := []string{rc.opts.DayHeader, rc.opts.DateHeader, rc.opts.NameHeader, rc.opts.TimeHeader, rc.opts.TimeClock.PunchedHeader}
+	for _, t := range slots {
+		headers = append(headers, fmt.Sprintf("%02d:00", t/60%24))
+	}
+	headers = append(headers, rc.theme.Late.Label, rc.theme.Early.Label, rc.theme.Extra.Label, rc.opts.TimeClock.StatusHeader)
+	lastCol := len(headers)
+	cell := func(col, row int) string {
+		name, _ := excelize.CoordinatesToCellName(col, row)
+		return name
+	}
+
+	if err := f.MergeCell(sheet, cell(1, 1), cell(lastCol, 1)); err != nil {
+		return fmt.Errorf("error merging cells: %v", err)
+	}
+	f.SetCellValue(sheet, cell(1, 1), title)
+	f.SetCellStyle(sheet, cell(1, 1), cell(lastCol, 1), rc.styles.title)
+	for i, header := range headers {
+		f.SetCellValue(sheet, cell(i+1, 2), header)
+	}
+	f.SetCellStyle(sheet, cell(1, 2), cell(lastCol, 2), rc.styles.header)
+
+	for i, r := range rows {
+		row := i + 3
+		date, _ := time.Parse(time.DateOnly, r.date)
+		f.SetCellValue(sheet, cell(1, row), date.Weekday().String())
+		f.SetCellValue(sheet, cell(2, row), r.date)
+		f.SetCellValue(sheet, cell(3, row), r.name)
+		f.SetCellStyle(sheet, cell(1, row), cell(3, row), rc.styles.name)
+		if r.planned != nil {
+			f.SetCellValue(sheet, cell(4, row), r.planned.Time)
+		}
+		if r.punch != nil {
+			f.SetCellValue(sheet, cell(5, row), cmp.Or(r.punch.In, "?")+" - "+cmp.Or(r.punch.Out, "?"))
+		}
+		for j, t := range slots {
+			f.SetCellStyle(sheet, cell(6+j, row), cell(6+j, row), r.slotStyle(t, rc.styles))
+		}
+		col := 6 + len(slots)
+		for j, minutes := range []int{r.late, r.early, r.extra} {
+			if minutes > 0 {
+				f.SetCellValue(sheet, cell(col+j, row), minutes)
+			}
+		}
+		f.SetCellValue(sheet, cell(col+3, row), r.status(rc.theme))
+	}
+
+	// Legend of the deviation colours
+	row := len(rows) + 4
+	f.SetCellValue(sheet, cell(1, row), rc.theme.LegendTitle)
+	for i, legend := range []struct {
+		label string
+		style int
+	}{
+		{rc.theme.Late.Label, rc.styles.late},
+		{rc.theme.Early.Label, rc.styles.early},
+		{rc.theme.Missing.Label, rc.styles.missing},
+		{rc.theme.Extra.Label, rc.styles.extra},
+	} {
+		f.SetCellValue(sheet, cell(2+i, row), legend.label)
+		f.SetCellStyle(sheet, cell(2+i, row), cell(2+i, row), legend.style)
+	}
+
+	f.SetColWidth(sheet, "A", "B", 12)
+	f.SetColWidth(sheet, "C", "C", 25)
+	f.SetColWidth(sheet, "D", "E", 15)
+	return nil
+}
+
+// writeDeviationSheet writes the planned and actual hours and the deviations
+// of each employee for the week
+func writeDeviationSheet(f *excelize.File, sheet string, rows []punchRow, title string, rc renderContext) error {
+	type total struct {
+		name               string
+		id                 int
+		planned, actual    float64
+		late, early, extra int
+		missing            int
+	}
+	totals := []*total{}
+	byId := map[int]*total{}
+	for _, r := range rows {
+		t, ok := byId[r.employeeId]
+		if !ok {
+			t = &total{name: r.name, id: r.employeeId}
+			byId[r.employeeId] = t
+			totals = append(totals, t)
+		}
+		t.planned += r.plannedHours()
+		t.actual += r.actualHours()
+		t.late += r.late
+		t.early += r.early
+		t.extra += r.extra
+		if r.missing {
+			t.missing++
+		}
+	}
+	slices.SortFunc(totals, func(a, b *total) int { return cmp.Or(strings.Compare(a.name, b.name), a.id-b.id) })
+
+	clock := rc.opts.TimeClock
+	headers := []string{rc.opts.NameHeader, clock.PlannedHeader, clock.PunchedHeader, clock.DiffHeader,
+		rc.theme.Late.Label + " (min)", rc.theme.Early.Label + " (min)", rc.theme.Extra.Label + " (min)", rc.theme.Missing.Label}
+	cell := func(col, row int) string {
+		name, _ := excelize.CoordinatesToCellName(col, row)
+		return name
+	}
+	if err := f.MergeCell(sheet, cell(1, 1), cell(len(headers), 1)); err != nil {
+		return fmt.Errorf("error merging cells: %v", err)
+	}
+	f.SetCellValue(sheet, cell(1, 1), title)
+	f.SetCellStyle(sheet, cell(1, 1), cell(len(headers), 1), rc.styles.title)
+	for i, header := range headers {
+		f.SetCellValue(sheet, cell(i+1, 2), header)
+	}
+	f.SetCellStyle(sheet, cell(1, 2), cell(len(headers), 2), rc.styles.header)
+
+	for i, t := range totals {
+		row := i + 3
+		values := []any{t.name, roundHours(t.planned), roundHours(t.actual), roundHours(t.actual - t.planned), t.late, t.early, t.extra, t.missing}
+		for j, v := range values {
+			f.SetCellValue(sheet, cell(j+1, row), v)
+		}
+		f.SetCellStyle(sheet, cell(1, row), cell(1, row), rc.styles.name)
+		for j, style := range []int{rc.styles.late, rc.styles.early, rc.styles.extra, rc.styles.missing} {
+			if values[4+j] != 0 {
+				f.SetCellStyle(sheet, cell(5+j, row), cell(5+j, row), style)
+			}
+		}
+	}
+	f.SetColWidth(sheet, "A", "A", 25)
+	f.SetColWidth(sheet, "B", "H", 14)
+	return nil
+}
+
+// roundHours keeps two decimals
+func roundHours(h float64) float64 {
+	return math.Round(h*100) / 100
+}
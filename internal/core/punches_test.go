@@ -0,0 +1,122 @@
+package core
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/xuri/excelize/v2"
+)
+
+// Anna is late and leaves early on Monday and never punches Wednesday, Erik
+// stays an hour extra on Tuesday and works an unplanned Thursday
+const punchesCSV = `employeeId;date;clock-in;clock-out
+1;2025-03-17;09:12;16:40:00
+2;2025-03-18;10:03;15:00
+2;2025-03-20;08:00;12:00
+`
+
+func TestComparePunches(t *testing.T) {
+	punches, err := readPunches(strings.NewReader(punchesCSV))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	shifts, err := ReadShifts(buildInputFile(t, employeeShifts), nil, DefaultProcessOptions())
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	rows := comparePunches(shifts, punches, nil, DefaultProcessOptions())
+	if len(rows) != 4 {
+		t.Fatalf("expected three shifts and one unplanned punch, got %d rows", len(rows))
+	}
+	if rows[0].late != 12 || rows[0].early != 20 || rows[0].extra != 0 {
+		t.Fatalf("unexpected Monday deviations %+v", rows[0])
+	}
+	if rows[1].late != 0 || rows[1].extra != 60 {
+		t.Fatalf("expected 3 minutes late to be within the tolerance and an extra hour, got %+v", rows[1])
+	}
+	if !rows[2].missing || rows[2].planned == nil || rows[3].planned != nil || rows[3].extra != 240 || rows[3].name != "Id 2" {
+		t.Fatalf("unexpected missing or unplanned rows %+v %+v", rows[2], rows[3])
+	}
+
+	if _, err := readPunches(strings.NewReader("employeeId,date,in,out\n1,2025-03-17,nine,17:00\n")); err == nil {
+		t.Fatalf("expected an invalid clock-in to fail")
+	}
+}
+
+func TestComparePunchesSplitDay(t *testing.T) {
+	shifts, err := ReadShifts(buildInputFile(t, [][]string{
+		{"1", "Svensson", "Anna", "Pass", "2025-03-17", "09:00 - 12:00", "Kassa"},
+		{"1", "Svensson", "Anna", "Pass", "2025-03-17", "14:00 - 17:00", "Kassa"},
+	}), nil, DefaultProcessOptions())
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	// Only the afternoon was punched
+	punches := []Punch{{EmployeeId: 1, Date: "2025-03-17", In: "14:02", Out: "17:00"}}
+	rows := comparePunches(shifts, punches, nil, DefaultProcessOptions())
+	if len(rows) != 2 {
+		t.Fatalf("expected a row per shift, got %d rows", len(rows))
+	}
+	if !rows[0].missing || rows[0].planned.StartTime != "09:00:00" {
+		t.Fatalf("expected the morning missing, got %+v", rows[0])
+	}
+	if rows[1].missing || rows[1].punch == nil || rows[1].late != 0 || rows[1].early != 0 || rows[1].extra != 0 {
+		t.Fatalf("expected the afternoon on time, got %+v", rows[1])
+	}
+}
+
+func TestProcessFilesPunches(t *testing.T) {
+	opts := DefaultProcessOptions()
+	opts.Punches = strings.NewReader(punchesCSV)
+	opts.TimeClock.DiffHeader = "Diff"
+	result, err := ProcessFilesWithOptions(buildInputFile(t, employeeShifts), nil, nil, opts)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if result["Vecka 12.xlsx"] == nil || result["Vecka 12 - Utfall.xlsx"] == nil {
+		t.Fatalf("expected the comparison alongside the schedule, got %d files", len(result))
+	}
+	f, err := excelize.OpenReader(bytes.NewReader(result["Vecka 12 - Utfall.xlsx"]))
+	if err != nil {
+		t.Fatalf("opening result: %v", err)
+	}
+	defer f.Close()
+
+	// Slots run 08-16 in F:N, the deviations follow
+	expect := map[string]string{
+		"Utfall!C3":     "Anna Svensson",
+		"Utfall!E3":     "09:12 - 16:40",
+		"Utfall!O3":     "12",
+		"Utfall!P3":     "20",
+		"Utfall!R3":     "Sen, Gick tidigt",
+		"Utfall!Q4":     "60",
+		"Utfall!R5":     "Saknas",
+		"Utfall!C6":     "Erik Berg",
+		"Utfall!Q6":     "240",
+		"Utfall!E2":     "Stämplat",
+		"Avvikelser!D2": "Diff",
+		"Avvikelser!A3": "Anna Svensson",
+		"Avvikelser!B3": "11",
+		"Avvikelser!C3": "6.47",
+		"Avvikelser!D3": "-4.53",
+		"Avvikelser!H3": "1",
+		"Avvikelser!C4": "8.95",
+		"Avvikelser!G4": "300",
+	}
+	for ref, want := range expect {
+		sheet, cell, _ := strings.Cut(ref, "!")
+		if got, _ := f.GetCellValue(sheet, cell); got != want {
+			t.Fatalf("expected %q in %s, got %q", want, ref, got)
+		}
+	}
+
+	// Highlighted slots share the styles of the legend
+	for cell, legend := range map[string]string{"G3": "B8", "N3": "C8", "J5": "D8", "F6": "E8"} {
+		got, _ := f.GetCellStyle("Utfall", cell)
+		want, _ := f.GetCellStyle("Utfall", legend)
+		if got != want {
+			t.Fatalf("expected %s styled like legend %s", cell, legend)
+		}
+	}
+}
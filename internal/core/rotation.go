@@ -0,0 +1,200 @@
+package core
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/go-gota/gota/series"
+)
+
+// RotationOptions expands the base schedule (grundschema) into dated shifts
+type RotationOptions struct {
+	// A date in cycle week 1, the cycle starts on the Monday of that week
+	Start string `json:"start" toml:"start" yaml:"start"`
+	// Dates to expand, inclusive, YYYY-MM-DD
+	From string `json:"from" toml:"from" yaml:"from"`
+	To   string `json:"to" toml:"to" yaml:"to"`
+	// Weeks in the rotation, 0 uses the highest cycle week in the file
+	CycleWeeks int `json:"cycleWeeks" toml:"cycleWeeks" yaml:"cycleWeeks"`
+	// Shift types in the export that cancel the base shifts of that employee and day
+	CancelTypes []string `json:"cancelTypes" toml:"cancelTypes" yaml:"cancelTypes"`
+	// Shift type of the expanded shifts
+	ShiftType string `json:"shiftType" toml:"shiftType" yaml:"shiftType"`
+}
+
+func defaultRotationOptions() RotationOptions {
+	return RotationOptions{
+		CancelTypes: []string{"Ledig", "Frånvaro"},
+		ShiftType:   "Grundschema",
+	}
+}
+
+func (r RotationOptions) validate() error {
+	dates := map[string]string{"start": r.Start, "from": r.From, "to": r.To}
+	for _, name := range []string{"start", "from", "to"} {
+		if _, err := time.Parse(time.DateOnly, dates[name]); err != nil {
+			return fmt.Errorf("%s must be a date (YYYY-MM-DD), got %q", name, dates[name])
+		}
+	}
+	if r.To < r.From {
+		return fmt.Errorf("to %s is before from %s", r.To, r.From)
+	}
+	if r.CycleWeeks < 0 {
+		return fmt.Errorf("cycleWeeks must not be negative")
+	}
+	return nil
+}
+
+// baseShift is one row of the base schedule
+type baseShift struct {
+	employeeId string
+	lastName   string
+	firstName  string
+	department string
+	cycleWeek  int
+	weekday    time.Weekday
+	time       string // as in the export, e.g. "09:00 - 17:00"
+	role       string
+}
+
+// parseWeekday accepts English or Swedish names, or 1-7 from Monday
+func parseWeekday(s string) (time.Weekday, error) {
+	day := strings.ToLower(strings.TrimSpace(s))
+	if english, ok := swedishWeekdays[day]; ok {
+		day = english
+	}
+	for d := time.Sunday; d <= time.Saturday; d++ {
+		if strings.ToLower(d.String()) == day {
+			return d, nil
+		}
+	}
+	if n, err := strconv.Atoi(day); err == nil && n >= 1 && n <= 7 {
+		return time.Weekday(n % 7), nil
+	}
+	return 0, fmt.Errorf("invalid weekday %q", s)
+}
+
+/*
+================================================================================
+Read the base schedule: employeeId, lastName, firstName, department,
+cycleWeek, weekday, time and role
+================================================================================
+*/
+func readBaseSchedule(r io.Reader) ([]baseShift, error) {
+	rows, err := readTable(r)
+	if err != nil {
+		return nil, errors.New("Error reading base schedule: " + err.Error())
+	}
+	shifts := []baseShift{}
+	for i, record := range tableRecords(rows) {
+		week, err := strconv.Atoi(record["cycleweek"])
+		if err != nil || week < 1 {
+			return nil, fmt.Errorf("Error reading base schedule row %d: invalid cycleWeek %q", i+2, record["cycleweek"])
+		}
+		weekday, err := parseWeekday(record["weekday"])
+		if err != nil {
+			return nil, fmt.Errorf("Error reading base schedule row %d: %v", i+2, err)
+		}
+		if !strings.Contains(record["time"], " - ") {
+			return nil, fmt.Errorf("Error reading base schedule row %d: time must look like 09:00 - 17:00, got %q", i+2, record["time"])
+		}
+		shifts = append(shifts, baseShift{
+			employeeId: record["employeeid"],
+			lastName:   record["lastname"],
+			firstName:  record["firstname"],
+			department: record["department"],
+			cycleWeek:  week,
+			weekday:    weekday,
+			time:       record["time"],
+			role:       record["role"],
+		})
+	}
+	return shifts, nil
+}
+
+// expandRotation returns export rows for every base shift between from and
+// to, with the role of each row alongside
+func expandRotation(base []baseShift, opts RotationOptions) ([][]string, []string) {
+	start, _ := time.Parse(time.DateOnly, opts.Start)
+	start = start.AddDate(0, 0, -(int(start.Weekday())+6)%7) // Monday of cycle week 1
+	from, _ := time.Parse(time.DateOnly, opts.From)
+	to, _ := time.Parse(time.DateOnly, opts.To)
+	cycle := opts.CycleWeeks
+	if cycle == 0 {
+		for _, shift := range base {
+			cycle = max(cycle, shift.cycleWeek)
+		}
+	}
+	if cycle == 0 {
+		return nil, nil
+	}
+
+	rows, roles := [][]string{}, []string{}
+	for date := from; !date.After(to); date = date.AddDate(0, 0, 1) {
+		weeks := int(math.Floor(date.Sub(start).Hours() / (24 * 7)))
+		week := ((weeks%cycle)+cycle)%cycle + 1
+		for _, shift := range base {
+			if shift.cycleWeek != week || shift.weekday != date.Weekday() {
+				continue
+			}
+			rows = append(rows, []string{shift.employeeId, shift.lastName, shift.firstName, opts.ShiftType,
+				date.Format(time.DateOnly), shift.time, shift.department})
+			roles = append(roles, shift.role)
+		}
+	}
+	return rows, roles
+}
+
+/*
+================================================================================
+Merge the expanded base schedule with the export: an export row replaces the
+base shifts of that employee and date, cancel types only remove them
+================================================================================
+*/
+func mergeRotation(rows [][]string, opts ProcessOptions) ([][]string, []string, error) {
+	base, err := readBaseSchedule(opts.BaseSchedule)
+	if err != nil {
+		return nil, nil, err
+	}
+	if len(rows) < 2 {
+		rows = [][]string{{"Export"}, {"Id", "Efternamn", "Förnamn", "Typ", "Datum", "Tid", "Avdelning"}}
+	}
+
+	exceptions := map[string]bool{}
+	merged, roles := [][]string{rows[0], rows[1]}, []string{}
+	for _, row := range rows[2:] {
+		row = append(row, make([]string, max(0, 7-len(row)))...)
+		exceptions[row[0]+"|"+row[4]] = true
+		if slices.ContainsFunc(opts.Rotation.CancelTypes, func(t string) bool { return strings.EqualFold(t, row[3]) }) {
+			continue
+		}
+		merged = append(merged, row)
+		roles = append(roles, "")
+	}
+	expanded, expandedRoles := expandRotation(base, opts.Rotation)
+	for i, row := range expanded {
+		if exceptions[row[0]+"|"+row[4]] {
+			continue
+		}
+		merged = append(merged, row)
+		roles = append(roles, expandedRoles[i])
+	}
+	return merged, roles, nil
+}
+
+// overrideRoles puts the base schedule roles over those from the settings file
+func overrideRoles(s series.Series, roles []string) series.Series {
+	records := s.Records()
+	for i, role := range roles {
+		if role != "" && i < len(records) {
+			records[i] = role
+		}
+	}
+	return series.New(records, series.String, "role")
+}
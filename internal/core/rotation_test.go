@@ -0,0 +1,86 @@
+package core
+
+import (
+	"strings"
+	"testing"
+)
+
+const baseScheduleCSV = `employeeId,lastName,firstName,department,cycleWeek,weekday,time,role
+1,Svensson,Anna,Kassa,1,monday,09:00 - 17:00,
+1,Svensson,Anna,Kassa,2,tisdag,12:00 - 16:00,
+2,Berg,Erik,Kassa,1,3,10:00 - 14:00,Kassa
+2,Berg,Erik,Lager,2,thursday,08:00 - 12:00,Lager
+`
+
+func rotationOptions() ProcessOptions {
+	opts := DefaultProcessOptions()
+	opts.BaseSchedule = strings.NewReader(baseScheduleCSV)
+	opts.Rotation.Start = "2025-03-19"
+	opts.Rotation.From = "2025-03-17"
+	opts.Rotation.To = "2025-03-30"
+	return opts
+}
+
+func TestExpandRotation(t *testing.T) {
+	base, err := readBaseSchedule(strings.NewReader(baseScheduleCSV))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	opts := rotationOptions().Rotation
+	rows, roles := expandRotation(base, opts)
+	dates := []string{}
+	for _, row := range rows {
+		dates = append(dates, row[4])
+	}
+	if strings.Join(dates, " ") != "2025-03-17 2025-03-19 2025-03-25 2025-03-27" || roles[1] != "Kassa" {
+		t.Fatalf("unexpected expansion %v %v", dates, roles)
+	}
+
+	// Dates before the start count backwards through the cycle
+	opts.Start = "2025-03-24"
+	opts.To = "2025-03-23"
+	rows, _ = expandRotation(base, opts)
+	if len(rows) != 2 || rows[0][4] != "2025-03-18" || rows[1][4] != "2025-03-20" {
+		t.Fatalf("expected cycle week 2 before the start, got %v", rows)
+	}
+}
+
+func TestReadShiftsWithRotation(t *testing.T) {
+	opts := rotationOptions()
+	export := buildInputFile(t, [][]string{
+		{"1", "Svensson", "Anna", "Ledig", "2025-03-17", "00:00 - 00:00", "Kassa"},
+		{"2", "Berg", "Erik", "Pass", "2025-03-19", "12:00 - 18:00", "Kassa"},
+	})
+	shifts, err := ReadShifts(export, nil, opts)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	got := []string{}
+	for _, s := range shifts {
+		got = append(got, s.Date+" "+s.FirstName+" "+s.ShiftType+" "+s.Time+" "+s.Role)
+	}
+	want := []string{
+		"2025-03-19 Erik Pass 12:00 - 18:00 ",
+		"2025-03-25 Anna Grundschema 12:00 - 16:00 ",
+		"2025-03-27 Erik Grundschema 08:00 - 12:00 Lager",
+	}
+	if strings.Join(got, "\n") != strings.Join(want, "\n") {
+		t.Fatalf("unexpected shifts:\n%s", strings.Join(got, "\n"))
+	}
+}
+
+func TestProcessFilesRotationOnly(t *testing.T) {
+	result, err := ProcessFilesWithOptions(nil, nil, nil, rotationOptions())
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if result["Vecka 12.xlsx"] == nil || result["Vecka 13.xlsx"] == nil {
+		t.Fatalf("expected both weeks from the base schedule, got %d files", len(result))
+	}
+
+	opts := rotationOptions()
+	opts.Rotation.Start = ""
+	if _, err := ProcessFilesWithOptions(nil, nil, nil, opts); err == nil {
+		t.Fatalf("expected a missing start date to fail")
+	}
+}
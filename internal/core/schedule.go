@@ -33,6 +33,10 @@ type ShiftActivity struct {
 	role         string
 	phone        string
 	shiftLength  string
+	department   string
+	absence      *Absence // overlapping absence, nil when none
+	absentAll    bool     // the absence takes the whole shift
+	changed      string   // kind of change since the previous export, "" when unchanged
 }
 
 type DaySchedule struct {
@@ -43,44 +47,97 @@ type DaySchedule struct {
 	shifts  []ShiftActivity
 }
 
-var (
-	styleFree     = 0
-	styleWork     = 0
-	styleLunch    = 0
-	styleAssigned = 0
-	styleName     = 0
-	styleTitle    = 0
-	styleHeader   = 0
-)
-
-type FooterCell struct {
-	Row   int
-	Col   int
-	Value interface{}
-	Style *excelize.Style
-	Merge string // optional: e.g., "C20:D20"
+// ProcessFiles generates the week schedules using DefaultProcessOptions
+func ProcessFiles(input io.Reader, settings io.Reader, footer io.Reader) (map[string][]byte, error) {
+	return ProcessFilesWithOptions(input, settings, footer, DefaultProcessOptions())
 }
 
-func ProcessFiles(input io.Reader, settings io.Reader, footer io.Reader) (map[string][]byte, error) {
+// ProcessFilesWithOptions generates one workbook per week, keyed by file name
+func ProcessFilesWithOptions(input io.Reader, settings io.Reader, footer io.Reader, opts ProcessOptions) (map[string][]byte, error) {
 	log.Println("Processing files...")
+	if err := opts.Validate(); err != nil {
+		return nil, errors.New("Invalid options: " + err.Error())
+	}
 	var settingsDf dataframe.DataFrame
 	settingsDf, _ = readSettingsFile(settings)
-	df, err := readAndRefineInputData(input, settingsDf)
+	var previous []Shift
+	if opts.Previous != nil {
+		var err error
+		previous, err = previousShifts(opts.Previous, settingsDf, &opts)
+		if err != nil {
+			return nil, err
+		}
+	}
+	df, err := readAndRefineInputData(input, settingsDf, opts)
 	if err != nil {
 		return nil, errors.New("Error reading input data: " + err.Error())
 	}
+	var changes []ShiftChange
+	if opts.Previous != nil {
+		shifts, err := shiftsFromDataFrame(df)
+		if err != nil {
+			return nil, err
+		}
+		changes = DiffShifts(previous, shifts)
+	}
 
-	result, err := createWeekSchedules(df, footer)
+	result, err := createWeekSchedules(df, footer, changes, opts)
 	if err != nil {
 		return nil, errors.New("Error creating weekly schedules: " + err.Error())
 	}
+	if opts.Previous != nil {
+		report, err := WriteDiffReport(changes, opts)
+		if err != nil {
+			return nil, errors.New("Error creating change report: " + err.Error())
+		}
+		result[opts.Diff.FileName+".xlsx"] = report
+	}
+
+	if slices.Contains(opts.formats(), FormatICS) {
+		shifts, err := shiftsFromDataFrame(df)
+		if err != nil {
+			return nil, err
+		}
+		calendars, err := createCalendars(shifts, opts)
+		if err != nil {
+			return nil, errors.New("Error creating calendars: " + err.Error())
+		}
+		for name, content := range calendars {
+			result[name] = content
+		}
+	}
+	if slices.Contains(opts.formats(), FormatPayroll) {
+		shifts, err := shiftsFromDataFrame(df)
+		if err != nil {
+			return nil, err
+		}
+		payroll, err := createPayrollExports(shifts, opts)
+		if err != nil {
+			return nil, errors.New("Error creating payroll export: " + err.Error())
+		}
+		for name, content := range payroll {
+			result[name] = content
+		}
+	}
+	if slices.Contains(opts.formats(), FormatJSON) || slices.Contains(opts.formats(), FormatCSV) {
+		exports, err := createDataExports(df, opts)
+		if err != nil {
+			return nil, errors.New("Error creating data exports: " + err.Error())
+		}
+		for name, content := range exports {
+			result[name] = content
+		}
+	}
 
 	return result, nil
 }
 
 func readSettingsFile(r io.Reader) (dataframe.DataFrame, error) {
 	// Read settings file
-	// This file contains employeeId, phone and role
+	// This file contains employeeId, phone, role and optionally hourlyWage
+	if r == nil {
+		return dataframe.DataFrame{}, nil
+	}
 	sr, err := excelize.OpenReader(r)
 	if err != nil {
 		return dataframe.DataFrame{}, errors.New("Error opening settings file: " + err.Error())
@@ -90,40 +147,118 @@ func readSettingsFile(r io.Reader) (dataframe.DataFrame, error) {
 		return dataframe.DataFrame{}, errors.New("Error getting settings rows: " + err.Error())
 	}
 	if len(settingsData) < 2 {
-		fmt.Println("Settings file is empty or has no data")
+		log.Println("Settings file is empty or has no data")
 		return dataframe.DataFrame{}, nil
 	}
 	settingsDf := excelRowsToDataFrame(settingsData[1:]) // Skip header row
 	if settingsDf.Ncol() < 3 {
-		fmt.Println("Settings file does not have enough columns")
+		log.Println("Settings file does not have enough columns")
 		return dataframe.DataFrame{}, nil
 	}
-	if settingsDf.Ncol() > 3 {
-		fmt.Println("Settings file has more than 3 columns, only first 3 will be used")
-		settingsDf = settingsDf.Subset([]int{0, 1, 2}) // Keep only first 3 columns
+	if settingsDf.Ncol() > 4 {
+		log.Println("Settings file has more than 4 columns, only first 4 will be used")
+		settingsDf = settingsDf.Select([]int{0, 1, 2, 3}) // Keep only first 4 columns
 	}
-	err = settingsDf.SetNames("employeeId", "phone", "role")
+	names := []string{"employeeId", "phone", "role", "hourlyWage"}
+	err = settingsDf.SetNames(names[:settingsDf.Ncol()]...)
 	if err != nil {
-		fmt.Println("Error setting DataFrame column names:", err)
+		log.Println("Error setting DataFrame column names:", err)
 		return dataframe.DataFrame{}, nil
 	}
 
 	return settingsDf, nil
 }
 
+// renderContext carries everything shared by the day sheets of a run
+type renderContext struct {
+	opts      ProcessOptions
+	blocks    blockSet // footer and optional header block variants
+	dayValues map[string]map[string]string
+	notes     map[string][]DayNote // by date
+	holidays  HolidayCalendar
+	costs     laborCost                  // nil unless the cost estimate is enabled
+	demand    map[string]map[int]float64 // required staff by date and slot start
+	absences  []Absence
+	punches   []Punch
+	changed   map[string]string // change kind by "employeeId|date|time"
+	names     map[int]string    // employee names by id, for rows without a shift
+	template  *dayTemplate
+	theme     ThemeOptions
+	styles    sheetStyles // set per workbook
+}
+
 /*
 ================================================================================
 Create a excel workbook per week
 ================================================================================
 */
-func createWeekSchedules(df dataframe.DataFrame, footerReader io.Reader) (map[string][]byte, error) {
+func createWeekSchedules(df dataframe.DataFrame, footerReader io.Reader, changes []ShiftChange, opts ProcessOptions) (map[string][]byte, error) {
 	var results = make(map[string][]byte)
+	var resultsMu sync.Mutex
+	var errs []error
 
-	// Prepare footer
-	footer := []FooterCell{}
-	footer, err := PrepareFooter(footerReader, "Footer")
+	// Prepare footer, running without one is fine
+	var blocks blockSet
+	var err error
+	if footerReader != nil {
+		blocks, err = prepareBlocks(footerReader, opts)
+		if err != nil {
+			log.Println("Error preparing footer:", err, "- footer will not be applied")
+		}
+	}
+	rc := renderContext{opts: opts, blocks: blocks, holidays: NewHolidayCalendar(opts.Holidays), names: employeeNames(df),
+		changed: changedShifts(changes)}
+	if opts.DayValues != nil {
+		rc.dayValues, err = readDayValues(opts.DayValues)
+		if err != nil {
+			return nil, err
+		}
+	}
+	if opts.Notes != nil {
+		rc.notes, err = readNotes(opts.Notes)
+		if err != nil {
+			return nil, err
+		}
+	}
+	if opts.Demand != nil {
+		rc.demand, err = readDemand(opts.Demand, opts.Staffing)
+		if err != nil {
+			return nil, err
+		}
+	}
+	if opts.Absences != nil {
+		rc.absences, err = readAbsences(opts.Absences)
+		if err != nil {
+			return nil, err
+		}
+	}
+	if opts.Punches != nil {
+		rc.punches, err = readPunches(opts.Punches)
+		if err != nil {
+			return nil, err
+		}
+	}
+	if opts.Cost.Enabled {
+		shifts, err := shiftsFromDataFrame(df)
+		if err != nil {
+			return nil, err
+		}
+		rc.costs, err = estimateLaborCost(shifts, opts)
+		if err != nil {
+			return nil, errors.New("Error estimating labour cost: " + err.Error())
+		}
+	}
+	rc.theme, err = resolveTheme(opts.Theme)
 	if err != nil {
-		fmt.Println("Error preparing footer:", err, " - footer will not be applied")
+		return nil, errors.New("Error resolving theme: " + err.Error())
+	}
+
+	// Prepare layout template
+	if opts.Template != nil {
+		rc.template, err = prepareTemplate(opts.Template, opts.TemplateSheet)
+		if err != nil {
+			return nil, errors.New("Error preparing template: " + err.Error())
+		}
 	}
 
 	// Create a new file for each week
@@ -131,45 +266,218 @@ func createWeekSchedules(df dataframe.DataFrame, footerReader io.Reader) (map[st
 	wg.Add(len(df.GroupBy("weekNumber").GetGroups()))
 
 	for weekNumber, weekDf := range df.GroupBy("weekNumber").GetGroups() {
-		fmt.Printf("Processing weekNumber: %v\n", weekNumber)
+		log.Printf("Processing weekNumber: %v", weekNumber)
 
 		go func() {
 			defer wg.Done()
-			f := excelize.NewFile()
-			defer f.Close()
-			setStyles(f)
+			name := opts.fileName(weekNumber, weekYear(weekDf))
+			// A panic in a week would take the whole process down, report it instead
+			defer func() {
+				if r := recover(); r != nil {
+					resultsMu.Lock()
+					defer resultsMu.Unlock()
+					errs = append(errs, fmt.Errorf("%s: panic: %v", name, r))
+				}
+			}()
+			files, err := renderWeek(weekDf, name, rc)
+			resultsMu.Lock()
+			defer resultsMu.Unlock()
+			if err != nil {
+				errs = append(errs, fmt.Errorf("%s: %v", name, err))
+				return
+			}
+			for fn, buf := range files {
+				results[fn] = buf
+			}
+		}()
+	}
+
+	wg.Wait()
+	if len(errs) > 0 {
+		return nil, errors.Join(errs...)
+	}
+	return results, nil
+}
 
-			for _, dateDf := range weekDf.GroupBy("date").GetGroups() {
-				err := createDaySchedule(f, dateDf, footer)
+// weekView is one week prepared for rendering
+type weekView struct {
+	name      string // output name without extension
+	days      []dayView
+	employees []employeeWeek // only set when an employee view is requested
+	slots     []string       // hour slots shared by the employee rows
+	unstaffed []demandGap    // demand of the dates without shifts
+}
+
+// renderWeek produces the files of one week in every requested format
+func renderWeek(weekDf dataframe.DataFrame, name string, rc renderContext) (map[string][]byte, error) {
+	views, err := weekDayViews(weekDf, rc)
+	if err != nil {
+		return nil, err
+	}
+	week := weekView{name: name, days: views, unstaffed: unstaffedDemand(weekDf, rc.demand)}
+	if rc.opts.EmployeeView != "" || rc.opts.Overview {
+		week.employees, week.slots, err = employeeWeeks(weekDf, rc.opts)
+		if err != nil {
+			return nil, err
+		}
+	}
+	files := map[string][]byte{}
+	for _, format := range rc.opts.formats() {
+		switch format {
+		case FormatXLSX:
+			buf, err := createWeekWorkbook(week, rc)
+			if err != nil {
+				return nil, err
+			}
+			files[name+".xlsx"] = buf
+			// Planned against actual alongside the schedule
+			if rc.punches != nil {
+				buf, err := createComparisonWorkbook(weekDf, name, rc)
 				if err != nil {
-					panic(err)
+					return nil, err
 				}
+				files[name+" - "+rc.opts.TimeClock.FileSuffix+".xlsx"] = buf
 			}
-
-			// Swap sort sheets, use auto created "Sheet1" as anchor (since move is only way to reorder sheets)
-			sheetNames := f.GetSheetList()
-			for _, day := range []string{"Monday", "Tuesday", "Wednesday", "Thursday", "Friday", "Saturday", "Sunday"} {
-				if slices.Contains(sheetNames, day) {
-					f.MoveSheet(day, "Sheet1")
+			if rc.opts.EmployeeView != EmployeeViewWorkbooks {
+				continue
+			}
+			for _, employee := range week.employees {
+				buf, err := createEmployeeWorkbook(employee, week.slots, name, rc)
+				if err != nil {
+					return nil, err
 				}
+				files[name+" - "+safeFileName(employee.name)+" "+strconv.Itoa(employee.id)+".xlsx"] = buf
 			}
-			f.DeleteSheet("Sheet1")
-
-			fn := fmt.Sprintf("Vecka %v.xlsx", weekNumber)
-			results[fn] = nil // Initialize the map entry
-			// Save the file to a buffer
-			var err error
-			buf, err := f.WriteToBuffer() //TODO: intermitten bug "concurrent map writes"
+		case FormatPDF:
+			if err := createWeekPDFs(files, name, views, rc); err != nil {
+				return nil, err
+			}
+		case FormatHTML:
+			buf, err := renderHTML(name, views, rc)
 			if err != nil {
-				panic(fmt.Errorf("error writing to buffer: %v", err))
+				return nil, err
 			}
-			results[fn] = buf.Bytes()
-			// f.SaveAs(fmt.Sprintf("Vecka %v.xlsx", weekNumber))
-		}()
+			files[name+".html"] = buf
+		}
 	}
+	return files, nil
+}
 
-	wg.Wait()
-	return results, nil
+// Render the day sheets of one week into a workbook
+func createWeekWorkbook(week weekView, rc renderContext) ([]byte, error) {
+	// Sheet used as anchor for sorting, removed when done
+	anchor := "Sheet1"
+	var f *excelize.File
+	if rc.template != nil {
+		var err error
+		f, err = rc.template.newWorkbook()
+		if err != nil {
+			return nil, err
+		}
+		anchor = rc.template.sheet
+	} else {
+		f = excelize.NewFile()
+	}
+	defer f.Close()
+	styles, err := setStyles(f, rc.theme)
+	if err != nil {
+		return nil, err
+	}
+	rc.styles = styles
+
+	printRanges := map[string]printRange{}
+	for _, view := range week.days {
+		sheet, pr, err := createDaySchedule(f, view, rc)
+		if err != nil {
+			return nil, err
+		}
+		printRanges[sheet] = pr
+	}
+
+	// Swap sort sheets, use anchor sheet (since move is only way to reorder sheets)
+	sheetNames := f.GetSheetList()
+	for _, day := range []string{"Monday", "Tuesday", "Wednesday", "Thursday", "Friday", "Saturday", "Sunday"} {
+		if slices.Contains(sheetNames, day) {
+			f.MoveSheet(day, anchor)
+		}
+	}
+	f.DeleteSheet(anchor)
+
+	if rc.opts.Overview {
+		if err := writeOverviewSheet(f, week, rc); err != nil {
+			return nil, err
+		}
+	}
+
+	// Without the overview the week total gets a sheet of its own
+	if rc.costs != nil && !rc.opts.Overview {
+		if err := writeCostSheet(f, week, rc); err != nil {
+			return nil, err
+		}
+	}
+
+	// Worst gaps of the week after the days
+	if len(week.unstaffed) > 0 || slices.ContainsFunc(week.days, func(d dayView) bool { return d.demand != nil }) {
+		if err := writeDemandSheet(f, week, rc); err != nil {
+			return nil, err
+		}
+	}
+
+	// Employee sheets go after the days
+	if rc.opts.EmployeeView == EmployeeViewSheets {
+		for _, employee := range week.employees {
+			sheet := employeeSheetName(employee)
+			if _, err := f.NewSheet(sheet); err != nil {
+				return nil, err
+			}
+			if err := writeEmployeeSheet(f, sheet, employee, week.slots, week.name, rc); err != nil {
+				return nil, err
+			}
+		}
+	}
+	for sheet, pr := range printRanges {
+		if err := setPrintNames(f, sheet, rc.opts.Page, pr); err != nil {
+			return nil, err
+		}
+	}
+
+	// Save the file to a buffer
+	buf, err := f.WriteToBuffer()
+	if err != nil {
+		return nil, fmt.Errorf("error writing to buffer: %v", err)
+	}
+	return buf.Bytes(), nil
+}
+
+// dayLayout positions the blocks of a day sheet, rows and columns are 1-based
+type dayLayout struct {
+	titleRow  int
+	titleCol  int
+	headerRow int
+	gridCol   int // time column, name and phone follow, then the hour slots
+	shiftRow  int // first shift row
+	footerRow int // first footer row, 0 places it below the trailing header
+	legendRow int // 0 places the legend below the trailing header
+	notesRow  int // 0 places the notes box below the legend
+	templated bool
+
+	// Styles taken from the template, 0 uses the built-in style
+	titleStyle  int
+	headerStyle int
+	timeStyle   int
+	nameStyle   int
+	phoneStyle  int
+}
+
+func defaultDayLayout() dayLayout {
+	return dayLayout{titleRow: 1, titleCol: 1, headerRow: 2, gridCol: 1, shiftRow: 3}
+}
+
+func styleOr(style int, fallback int) int {
+	if style != 0 {
+		return style
+	}
+	return fallback
 }
 
 /*
@@ -177,115 +485,342 @@ func createWeekSchedules(df dataframe.DataFrame, footerReader io.Reader) (map[st
 Create a sheet per day in the excel file
 ================================================================================
 */
-func createDaySchedule(file *excelize.File, dateDf dataframe.DataFrame, footer []FooterCell) error {
+// dayView is everything shown for one day, shared by all output formats
+type dayView struct {
+	data    DaySchedule
+	values  map[string]string // placeholder values
+	header  Footer
+	footer  Footer
+	notes   []DayNote
+	holiday *Holiday
+	// Labour cost per hour slot and for the whole day, when estimated
+	slotCosts []float64
+	cost      float64
+	// Staffing against the demand file, nil without demand for the date
+	demand []demandGap
+	// Employees away during the day, listed under the grid
+	absent []dayAbsence
+}
+
+// title is shown above the grid, holidays are named
+func (v dayView) title() string {
+	title := v.data.dayStr + " - " + v.data.dateStr
+	if v.holiday != nil {
+		title += " - " + v.holiday.Name
+	}
+	return title
+}
+
+// newDayView parses the shifts of one date and resolves its blocks and notes
+func newDayView(dateDf dataframe.DataFrame, rc renderContext) (dayView, error) {
 	dateDf = dateDf.Arrange(
 		dataframe.Sort("startTime"),
 		dataframe.Sort("endTime"),
 	)
-	dayData, err := parseDayData(dateDf)
+	dayData, err := parseDayData(dateDf, rc.opts)
 	if err != nil {
-		return errors.New("error getting day schedule: " + err.Error())
+		return dayView{}, errors.New("error getting day schedule: " + err.Error())
+	}
+	// Absences are marked first so that counts leave out struck shifts
+	var absent []dayAbsence
+	if absences := absencesOn(rc.absences, dayData.dateStr); len(absences) > 0 {
+		absent, err = markAbsences(&dayData, dateDf, absences, rc.names)
+		if err != nil {
+			return dayView{}, err
+		}
+	}
+	for i, shift := range dayData.shifts {
+		dayData.shifts[i].changed = rc.changed[strconv.Itoa(shift.employeeId)+"|"+dayData.dateStr+"|"+shift.shiftTime]
+	}
+	department := singleDepartment(dateDf)
+	view := dayView{
+		data:   dayData,
+		values: dayPlaceholderValues(dayData, dateDf, rc.dayValues[dayData.dateStr]),
+		header: rc.blocks.resolve(rc.opts.HeaderSheet, dayData.dateStr, dayData.dayStr, department),
+		footer: rc.blocks.resolve(rc.opts.FooterSheet, dayData.dateStr, dayData.dayStr, department),
+		notes:  notesForDay(rc.notes[dayData.dateStr], dateDf.Col("department").Records()),
+		absent: absent,
+	}
+	if holiday, ok := rc.holidays.Lookup(dayData.dateStr); ok {
+		view.holiday = &holiday
+		if _, ok := view.values["holiday"]; !ok {
+			view.values["holiday"] = holiday.Name
+		}
+	}
+	if demand := rc.demand[dayData.dateStr]; demand != nil {
+		view.demand, err = dayDemand(dateDf, dayData, demand, rc.opts)
+		if err != nil {
+			return dayView{}, err
+		}
 	}
+	if rc.costs != nil {
+		view.slotCosts = rc.costs.slots(dayData.dateStr, dayData.headers[len(rc.opts.headers()):])
+		view.cost = rc.costs.day(dayData.dateStr)
+		if _, ok := view.values["cost"]; !ok {
+			view.values["cost"] = strconv.FormatFloat(roundCost(view.cost), 'f', 0, 64)
+		}
+	}
+	return view, nil
+}
+
+// weekDayViews returns the days of a week in date order
+func weekDayViews(weekDf dataframe.DataFrame, rc renderContext) ([]dayView, error) {
+	views := []dayView{}
+	for _, dateDf := range weekDf.GroupBy("date").GetGroups() {
+		view, err := newDayView(dateDf, rc)
+		if err != nil {
+			return nil, err
+		}
+		views = append(views, view)
+	}
+	slices.SortFunc(views, func(a, b dayView) int { return strings.Compare(a.data.dateStr, b.data.dateStr) })
+	return views, nil
+}
 
+// createDaySchedule returns the sheet name and what it prints
+func createDaySchedule(file *excelize.File, view dayView, rc renderContext) (string, printRange, error) {
+	var err error
+	dayData, header, footer, notes, values := view.data, view.header, view.footer, view.notes, view.values
 	sheetName := dayData.dayStr
-	file.NewSheet(sheetName)
+	layout := defaultDayLayout()
+	if rc.template != nil {
+		extra := 0
+		if view.slotCosts != nil {
+			extra++
+		}
+		if view.demand != nil {
+			extra++
+		}
+		layout, err = rc.template.newDaySheet(file, sheetName, len(dayData.shifts), extra, len(notes))
+		if err != nil {
+			return sheetName, printRange{}, fmt.Errorf("error creating sheet from template: %v", err)
+		}
+	} else {
+		file.NewSheet(sheetName)
+		// Room for the header block and a blank row
+		if !header.Empty() {
+			shift := header.Height() + 1
+			layout.titleRow += shift
+			layout.headerRow += shift
+			layout.shiftRow += shift
+		}
+	}
+	if !layout.templated && !header.Empty() {
+		if err := ApplyFooterToSheet(file, sheetName, header, 0, values); err != nil {
+			return sheetName, printRange{}, fmt.Errorf("error applying header block: %v", err)
+		}
+	}
 
-	totalOffset := 1
 	// Title row
-	titleStartCell, err := excelize.CoordinatesToCellName(1, 1)
+	titleStartCell, err := excelize.CoordinatesToCellName(layout.titleCol, layout.titleRow)
 	if err != nil {
-		return fmt.Errorf("error getting title start cell: %v", err)
+		return sheetName, printRange{}, fmt.Errorf("error getting title start cell: %v", err)
 	}
-	titleEndCell, err := excelize.CoordinatesToCellName(len(dayData.headers), 1)
+	titleEndCell, err := excelize.CoordinatesToCellName(layout.gridCol+len(dayData.headers)-1, layout.titleRow)
 	if err != nil {
-		return fmt.Errorf("error getting title end cell: %v", err)
+		return sheetName, printRange{}, fmt.Errorf("error getting title end cell: %v", err)
 	}
-	err = file.MergeCell(sheetName, titleStartCell, titleEndCell)
-	if err != nil {
-		return fmt.Errorf("error merging cells: %v", err)
+	if !layout.templated || !isMerged(file, sheetName, titleStartCell) {
+		err = file.MergeCell(sheetName, titleStartCell, titleEndCell)
+		if err != nil {
+			return sheetName, printRange{}, fmt.Errorf("error merging cells: %v", err)
+		}
+	}
+	file.SetCellValue(sheetName, titleStartCell, view.title())
+	titleStyle := styleOr(layout.titleStyle, rc.styles.title)
+	if view.holiday != nil {
+		titleStyle = rc.styles.holiday
 	}
-	file.SetCellValue(sheetName, titleStartCell, dayData.dayStr+" - "+dayData.dateStr)
-	file.SetCellStyle(sheetName, titleStartCell, titleEndCell, styleTitle)
-	totalOffset += 1
+	file.SetCellStyle(sheetName, titleStartCell, titleEndCell, titleStyle)
 
 	// Header row
-	for colIdx := 0; colIdx < len(dayData.headers); colIdx++ {
-		cell, err := excelize.CoordinatesToCellName(colIdx+1, 2)
-		if err != nil {
-			return fmt.Errorf("error calculating cell for header row %v", err)
-		}
-		file.SetCellValue(sheetName, cell, dayData.headers[colIdx])
-		file.SetCellStyle(sheetName, cell, cell, styleHeader)
+	if err := writeHeaderRow(file, sheetName, dayData.headers, layout, layout.headerRow, rc.styles); err != nil {
+		return sheetName, printRange{}, err
 	}
-	totalOffset += 1
 
-	// Shift rows (offset for previous rows
-	rowOffset := totalOffset
+	// Shift rows
+	rowOffset := layout.shiftRow
 	// First column with time data
-	hourOffset := 4
+	hourOffset := layout.gridCol + 3
 	for dataIdx := 0; dataIdx < len(dayData.shifts); dataIdx += 1 {
+		row := dataIdx + rowOffset
+		shift := dayData.shifts[dataIdx]
+		timeCell, _ := excelize.CoordinatesToCellName(layout.gridCol, row)
+		nameCell, _ := excelize.CoordinatesToCellName(layout.gridCol+1, row)
+		phoneCell, _ := excelize.CoordinatesToCellName(layout.gridCol+2, row)
 		// Time col
-		file.SetCellValue(sheetName, fmt.Sprintf("A%d", dataIdx+rowOffset), dayData.shifts[dataIdx].shiftTime)
-		file.SetColWidth(sheetName, "A", "A", 15)
+		file.SetCellValue(sheetName, timeCell, shift.shiftTime)
+		if shift.changed != "" {
+			file.SetCellStyle(sheetName, timeCell, timeCell, rc.styles.changed)
+		} else if layout.timeStyle != 0 {
+			file.SetCellStyle(sheetName, timeCell, timeCell, layout.timeStyle)
+		}
 		// Name col
-		file.SetCellValue(sheetName, fmt.Sprintf("B%d", dataIdx+rowOffset), dayData.shifts[dataIdx].employeeName)
-		file.SetCellStyle(sheetName, fmt.Sprintf("B%d", dataIdx+rowOffset), fmt.Sprintf("B%d", dataIdx+rowOffset), styleName)
-		file.SetColWidth(sheetName, "B", "B", 30)
+		file.SetCellValue(sheetName, nameCell, shift.employeeName)
+		nameStyle := styleOr(layout.nameStyle, rc.styles.name)
+		if shift.absentAll {
+			nameStyle = rc.styles.absent
+		} else if shift.absence != nil {
+			nameStyle = rc.styles.absentPartial
+		}
+		file.SetCellStyle(sheetName, nameCell, nameCell, nameStyle)
 		// Telephone col
-		file.SetCellValue(sheetName, fmt.Sprintf("C%d", dataIdx+rowOffset), dayData.shifts[dataIdx].phone)
+		file.SetCellValue(sheetName, phoneCell, shift.phone)
+		if layout.phoneStyle != 0 {
+			file.SetCellStyle(sheetName, phoneCell, phoneCell, layout.phoneStyle)
+		}
 		// Time cols
-		for hourIdx := 0; hourIdx < len(dayData.shifts[dataIdx].hourSchedule)-1; hourIdx++ { // Skip last hourSchedule since headers compacted by one!!!
-			cell, err := excelize.CoordinatesToCellName(hourIdx+hourOffset, dataIdx+rowOffset)
+		for hourIdx := 0; hourIdx < len(shift.hourSchedule)-1; hourIdx++ { // Skip last hourSchedule since headers compacted by one!!!
+			cell, err := excelize.CoordinatesToCellName(hourIdx+hourOffset, row)
 			if err != nil {
-				return fmt.Errorf("error calculating cell for hour %d, row %d: %v", hourIdx, dataIdx+rowOffset, err)
+				return sheetName, printRange{}, fmt.Errorf("error calculating cell for hour %d, row %d: %v", hourIdx, row, err)
 			}
 
 			// For each hour for current row, set the value and style
-			switch dayData.shifts[dataIdx].hourSchedule[hourIdx] {
-			case StateFree:
-				file.SetCellStyle(sheetName, cell, cell, styleFree)
-			case StateWork:
-				file.SetCellStyle(sheetName, cell, cell, styleWork)
+			state := shift.hourSchedule[hourIdx]
+			switch state {
 			case StateLunch:
 				file.SetCellValue(sheetName, cell, "Lunch")
-				file.SetCellStyle(sheetName, cell, cell, styleLunch)
 			case StateAssigned:
-				file.SetCellValue(sheetName, cell, dayData.shifts[dataIdx].role)
-				file.SetCellStyle(sheetName, cell, cell, styleAssigned)
+				file.SetCellValue(sheetName, cell, shift.role)
 			}
+			file.SetCellStyle(sheetName, cell, cell, rc.styles.activity(state, shift.role))
 		}
 		// Total time
-		totalCol, err := excelize.CoordinatesToCellName(len(dayData.headers)+1, totalOffset)
+		totalCol, err := excelize.CoordinatesToCellName(layout.gridCol+len(dayData.headers), row)
 		if err != nil {
-			return errors.New("Error calculating totalCol" + err.Error())
+			return sheetName, printRange{}, errors.New("Error calculating totalCol" + err.Error())
 		}
-		file.SetCellValue(sheetName, totalCol, dayData.shifts[dataIdx].shiftLength)
+		file.SetCellValue(sheetName, totalCol, shift.shiftLength)
+	}
 
-		totalOffset += 1
+	// Column widths come from the template when one is used
+	if !layout.templated {
+		file.SetColWidth(sheetName, "A", "A", 15)
+		file.SetColWidth(sheetName, "B", "B", 30)
+		timeColStart, err := excelize.ColumnNumberToName(hourOffset)
+		if err != nil {
+			return sheetName, printRange{}, fmt.Errorf("error calculating time column start: %v", err)
+		}
+		timeColEnd, err := excelize.ColumnNumberToName(hourOffset + len(dayData.shifts[0].hourSchedule) - 1)
+		if err != nil {
+			return sheetName, printRange{}, fmt.Errorf("error calculating time column end: %v", err)
+		}
+		file.SetColWidth(sheetName, timeColStart, timeColEnd, 12)
 	}
-	timeColStart, err := excelize.ColumnNumberToName(hourOffset)
-	if err != nil {
-		return fmt.Errorf("error calculating time column start: %v", err)
+
+	// Header row (trailing)
+	trailingRow := rowOffset + len(dayData.shifts)
+	if err := writeHeaderRow(file, sheetName, dayData.headers, layout, trailingRow, rc.styles); err != nil {
+		return sheetName, printRange{}, err
+	}
+	nextRow := trailingRow + 2
+
+	// Labour cost per slot and for the day
+	if view.slotCosts != nil {
+		if err := writeCostRow(file, sheetName, view, rc, layout, trailingRow+1); err != nil {
+			return sheetName, printRange{}, err
+		}
+		nextRow++
+	}
+	// Staffing against demand
+	if view.demand != nil {
+		if err := writeDemandRow(file, sheetName, view, rc, layout, nextRow-1); err != nil {
+			return sheetName, printRange{}, err
+		}
+		nextRow++
+	}
+
+	// Absent employees, templates have no place for them
+	if len(view.absent) > 0 && !layout.templated {
+		if err := writeAbsences(file, sheetName, view.absent, rc.theme, rc.styles, layout.gridCol, len(dayData.headers), nextRow); err != nil {
+			return sheetName, printRange{}, err
+		}
+		nextRow += len(view.absent) + 2
 	}
-	timeColEnd, err := excelize.ColumnNumberToName(hourOffset + len(dayData.shifts[0].hourSchedule) - 1)
+
+	// Legend explaining the colours
+	if rc.theme.Legend && (!layout.templated || layout.legendRow != 0) {
+		legendRow := layout.legendRow
+		if legendRow == 0 {
+			legendRow = nextRow
+			nextRow += 2
+		}
+		if err := writeLegend(file, sheetName, rc.theme, rc.styles, layout.gridCol, legendRow); err != nil {
+			return sheetName, printRange{}, err
+		}
+	}
+
+	// Notes box between the grid and the footer
+	if len(notes) > 0 && (!layout.templated || layout.notesRow != 0) {
+		notesRow := layout.notesRow
+		if notesRow == 0 {
+			notesRow = nextRow
+			nextRow += len(notes) + 2
+		}
+		if err := writeNotes(file, sheetName, notes, rc.theme, rc.styles, layout.gridCol, len(dayData.headers), notesRow); err != nil {
+			return sheetName, printRange{}, err
+		}
+	}
+
+	footerRow := layout.footerRow
+	if footerRow == 0 {
+		footerRow = nextRow
+	}
+	if err := ApplyFooterToSheet(file, sheetName, footer, footerRow-1, values); err != nil {
+		return sheetName, printRange{}, fmt.Errorf("error applying footer: %v", err)
+	}
+
+	if err := applyPageSetup(file, sheetName, rc.opts.Page, layout.templated, values); err != nil {
+		return sheetName, printRange{}, err
+	}
+	pr := printRange{titleRow: layout.titleRow, headerRow: layout.headerRow, lastCol: layout.gridCol + len(dayData.headers)}
+	rows, _ := file.GetRows(sheetName)
+	pr.lastRow = len(rows)
+	for _, row := range rows {
+		pr.lastCol = max(pr.lastCol, len(row))
+	}
+	return sheetName, pr, nil
+}
+
+// writeCostRow writes the cost of each slot under its column and the day total last
+func writeCostRow(file *excelize.File, sheetName string, view dayView, rc renderContext, layout dayLayout, row int) error {
+	labelCell, err := excelize.CoordinatesToCellName(layout.gridCol, row)
 	if err != nil {
-		return fmt.Errorf("error calculating time column end: %v", err)
+		return fmt.Errorf("error calculating cost cell: %v", err)
+	}
+	file.SetCellValue(sheetName, labelCell, rc.opts.Cost.Header)
+	hourOffset := layout.gridCol + len(rc.opts.headers())
+	for i, cost := range view.slotCosts {
+		cell, _ := excelize.CoordinatesToCellName(hourOffset+i, row)
+		file.SetCellValue(sheetName, cell, roundCost(cost))
+	}
+	totalCell, _ := excelize.CoordinatesToCellName(layout.gridCol+len(view.data.headers), row)
+	file.SetCellValue(sheetName, totalCell, roundCost(view.cost))
+	return file.SetCellStyle(sheetName, labelCell, totalCell, styleOr(layout.headerStyle, rc.styles.header))
+}
+
+// singleDepartment returns the department when all shifts of the day share one
+func singleDepartment(dateDf dataframe.DataFrame) string {
+	department := ""
+	for i, d := range dateDf.Col("department").Records() {
+		if i > 0 && d != department {
+			return ""
+		}
+		department = d
 	}
-	file.SetColWidth(sheetName, timeColStart, timeColEnd, 12)
+	return department
+}
 
-	// Header row (trailing)
-	for colIdx := 0; colIdx < len(dayData.headers); colIdx++ {
-		cell, err := excelize.CoordinatesToCellName(colIdx+1, totalOffset)
+func writeHeaderRow(file *excelize.File, sheetName string, headers []string, layout dayLayout, row int, styles sheetStyles) error {
+	for colIdx := 0; colIdx < len(headers); colIdx++ {
+		cell, err := excelize.CoordinatesToCellName(layout.gridCol+colIdx, row)
 		if err != nil {
 			return fmt.Errorf("error calculating cell for header row %v", err)
 		}
-		file.SetCellValue(sheetName, cell, dayData.headers[colIdx])
-		file.SetCellStyle(sheetName, cell, cell, styleHeader)
+		file.SetCellValue(sheetName, cell, headers[colIdx])
+		file.SetCellStyle(sheetName, cell, cell, styleOr(layout.headerStyle, styles.header))
 	}
-	totalOffset += 1
-
-	ApplyFooterToSheet(file, sheetName, footer, totalOffset)
-
 	return nil
 }
 
@@ -294,25 +829,37 @@ func createDaySchedule(file *excelize.File, dateDf dataframe.DataFrame, footer [
 Read and refine input file with time data
 ================================================================================
 */
-func readAndRefineInputData(r io.Reader, settingsDf dataframe.DataFrame) (dataframe.DataFrame, error) {
-	fr, err := excelize.OpenReader(r)
-	if err != nil {
-		return dataframe.DataFrame{}, errors.New("Error opening file: " + err.Error())
-	}
+func readAndRefineInputData(r io.Reader, settingsDf dataframe.DataFrame, opts ProcessOptions) (dataframe.DataFrame, error) {
+	var rows [][]string
+	// The export is optional when a base schedule is expanded
+	if r != nil || opts.BaseSchedule == nil {
+		fr, err := excelize.OpenReader(r)
+		if err != nil {
+			return dataframe.DataFrame{}, errors.New("Error opening file: " + err.Error())
+		}
 
-	rows, err := fr.GetRows("Worksheet")
-	if err != nil {
-		return dataframe.DataFrame{}, errors.New("Error getting rows: " + err.Error())
+		rows, err = fr.GetRows(opts.InputSheet)
+		if err != nil {
+			return dataframe.DataFrame{}, errors.New("Error getting rows: " + err.Error())
+		}
+	}
+	var roles []string
+	if opts.BaseSchedule != nil {
+		var err error
+		rows, roles, err = mergeRotation(rows, opts)
+		if err != nil {
+			return dataframe.DataFrame{}, err
+		}
 	}
 
 	df := excelRowsToDataFrame(rows[1:])
-	err = df.SetNames("employeeId", "lastName", "firstName", "shiftType", "date", "time", "department")
+	err := df.SetNames("employeeId", "lastName", "firstName", "shiftType", "date", "time", "department")
 	if err != nil {
 		return dataframe.DataFrame{}, errors.New("Error setting column names: " + err.Error())
 	}
 
 	df = df.Mutate(series.New(fixEmployeeId(df.Col("employeeId")), series.Int, "employeeId"))
-	shiftSeries, err := extractShiftDetails(df.Col("time"))
+	shiftSeries, err := extractShiftDetails(df.Col("time"), opts)
 	if err != nil {
 		return dataframe.DataFrame{}, errors.New("Error extracting shift details: " + err.Error())
 	}
@@ -327,6 +874,14 @@ func readAndRefineInputData(r io.Reader, settingsDf dataframe.DataFrame) (datafr
 	for _, s := range settingsCols {
 		df = df.Mutate(s)
 	}
+	if roles != nil {
+		df = df.Mutate(overrideRoles(df.Col("role"), roles))
+	}
+	wageCol, err := extractWageCol(df.Col("employeeId"), settingsDf, opts.Cost.Enabled)
+	if err != nil {
+		return dataframe.DataFrame{}, errors.New("Error extracting hourly wages: " + err.Error())
+	}
+	df = df.Mutate(wageCol)
 
 	weekNumSeries, err := extractWeekNumber(df.Col("date"))
 	if err != nil {
@@ -374,6 +929,34 @@ func extractSettingsCols(s series.Series, dfSettings dataframe.DataFrame) ([]ser
 	}, nil
 }
 
+// Hourly wage from the optional fourth settings column, 0 when missing or the cost estimate is off
+func extractWageCol(s series.Series, dfSettings dataframe.DataFrame, enabled bool) (series.Series, error) {
+	wages := make([]float64, len(s.Records()))
+	// Only the cost estimate uses wages, without it the fourth column may hold anything
+	if !enabled || !slices.Contains(dfSettings.Names(), "hourlyWage") {
+		return series.New(wages, series.Float, "hourlyWage"), nil
+	}
+	byId := map[string]string{}
+	ids := dfSettings.Col("employeeId").Records()
+	for i, wage := range dfSettings.Col("hourlyWage").Records() {
+		if _, ok := byId[ids[i]]; !ok {
+			byId[ids[i]] = wage
+		}
+	}
+	for i, id := range s.Records() {
+		wage := strings.TrimSpace(strings.ReplaceAll(byId[id], ",", "."))
+		if wage == "" || wage == "NaN" {
+			continue
+		}
+		value, err := strconv.ParseFloat(wage, 64)
+		if err != nil {
+			return series.Series{}, fmt.Errorf("invalid hourly wage %q for employee %s", byId[id], id)
+		}
+		wages[i] = value
+	}
+	return series.New(wages, series.Float, "hourlyWage"), nil
+}
+
 // Convert Excel rows to a gota dataframe
 func excelRowsToDataFrame(rows [][]string) dataframe.DataFrame {
 	if len(rows) == 0 {
@@ -412,7 +995,7 @@ func fixEmployeeId(s series.Series) series.Series {
 }
 
 // Add extra columns based on shift time: start/end times, length and has lunch
-func extractShiftDetails(s series.Series) ([]series.Series, error) {
+func extractShiftDetails(s series.Series, opts ProcessOptions) ([]series.Series, error) {
 	startTimes := make([]string, len(s.Records()))
 	endTimes := make([]string, len(s.Records()))
 	shiftLengths := make([]float64, len(s.Records()))
@@ -437,9 +1020,9 @@ func extractShiftDetails(s series.Series) ([]series.Series, error) {
 		endTimes[i] = en.Format(time.TimeOnly)
 
 		shiftLengths[i] = en.Sub(st).Hours()
-		if shiftLengths[i] > 5 {
+		if shiftLengths[i] > opts.LunchMinShiftHours {
 			hasLunch[i] = true
-			shiftLengths[i] -= 1 // Subtract 1 hour for lunch if shift is longer than 5 hours
+			shiftLengths[i] -= opts.LunchHours // Subtract lunch if shift is long enough
 		}
 	}
 	return []series.Series{
@@ -450,6 +1033,19 @@ func extractShiftDetails(s series.Series) ([]series.Series, error) {
 	}, nil
 }
 
+// ISO year of the first date in a week group, used in file names
+func weekYear(weekDf dataframe.DataFrame) string {
+	if weekDf.Nrow() == 0 {
+		return ""
+	}
+	date, err := time.Parse(time.DateOnly, weekDf.Col("date").Elem(0).String())
+	if err != nil {
+		return ""
+	}
+	year, _ := date.ISOWeek()
+	return strconv.Itoa(year)
+}
+
 // Add extra column based on date: week number
 func extractWeekNumber(s series.Series) (series.Series, error) {
 	weekNumbers := make([]int, len(s.Records()))
@@ -469,7 +1065,7 @@ func extractWeekNumber(s series.Series) (series.Series, error) {
 Produce data to be used in a day schedule sheet
 ================================================================================
 */
-func parseDayData(df dataframe.DataFrame) (DaySchedule, error) {
+func parseDayData(df dataframe.DataFrame, opts ProcessOptions) (DaySchedule, error) {
 	// Get earliest start
 	dayStart, err := time.Parse(
 		time.TimeOnly,
@@ -487,7 +1083,7 @@ func parseDayData(df dataframe.DataFrame) (DaySchedule, error) {
 		return DaySchedule{}, errors.New("Error parsing day end time: " + err.Error())
 	}
 	// Generate hour slots
-	hideBefore, err := time.Parse(time.TimeOnly, "10:00:00")
+	hideBefore, err := opts.hideBeforeTime()
 	if err != nil {
 		return DaySchedule{}, errors.New("Error parsing hideBefore time: " + err.Error())
 	}
@@ -498,7 +1094,8 @@ func parseDayData(df dataframe.DataFrame) (DaySchedule, error) {
 		}
 		timeSlots = append(timeSlots, t)
 	}
-	if dayEnd.After(timeSlots[len(timeSlots)-1]) {
+	// A day with every shift ending before hideBefore keeps its rows without hour columns
+	if len(timeSlots) > 0 && dayEnd.After(timeSlots[len(timeSlots)-1]) {
 		timeSlots = append(timeSlots, dayEnd)
 	}
 
@@ -528,6 +1125,7 @@ func parseDayData(df dataframe.DataFrame) (DaySchedule, error) {
 			shiftLength:  df.Col("shiftLength").Elem(rowIdx).String(),
 			role:         df.Col("role").Elem(rowIdx).String(),
 			phone:        df.Col("phone").Elem(rowIdx).String(),
+			department:   df.Col("department").Elem(rowIdx).String(),
 			hourSchedule: make([]HourActivity, len(timeSlots)),
 		}
 
@@ -535,8 +1133,8 @@ func parseDayData(df dataframe.DataFrame) (DaySchedule, error) {
 			if slot.Before(start) {
 				shiftRows[rowIdx].hourSchedule[hour] = StateFree
 			} else if !slot.Before(start) && slot.Before(end) {
-				// Lunch break after 5 hours
-				if hasLunch && slot == start.Add(5*time.Hour) {
+				// Lunch break after configured hours
+				if hasLunch && slot == start.Add(opts.lunchAfter()) {
 					shiftRows[rowIdx].hourSchedule[hour] = StateLunch
 				} else if shiftRows[rowIdx].role != "" {
 					shiftRows[rowIdx].hourSchedule[hour] = StateAssigned
@@ -551,7 +1149,7 @@ func parseDayData(df dataframe.DataFrame) (DaySchedule, error) {
 
 	// Compact headers from "09:00, 10:00, 11:00 => 09:00-10:00, 10:00-11:00"
 	// This shortens the list by one and shifts times left...
-	slotHeaders := make([]string, len(timeSlots)-1)
+	slotHeaders := make([]string, max(len(timeSlots)-1, 0))
 	for i := 0; i < len(timeSlots)-1; i++ {
 		slotHeaders[i] = timeSlots[i].Format("15:04") + "-" + timeSlots[i+1].Format("15:04")
 	}
@@ -566,224 +1164,8 @@ func parseDayData(df dataframe.DataFrame) (DaySchedule, error) {
 		dayStr:  date.Weekday().String(),
 		weekStr: df.Col("weekNumber").Elem(0).String(),
 		shifts:  shiftRows,
-		headers: append([]string{"Arbetstid", "Namn", "Tele"}, slotHeaders...),
+		headers: append(opts.headers(), slotHeaders...),
 	}
 
 	return daySchedule, nil
 }
-
-/*
-================================================================================
-Handle footer from file
-================================================================================
-*/
-
-// PrepareFooter extracts data+styles once and returns a reusable slice
-func PrepareFooter(r io.Reader, sheet string) ([]FooterCell, error) {
-	var footer []FooterCell
-
-	srcFile, err := excelize.OpenReader(r)
-	if err != nil {
-		return footer, errors.New("Error opening footer file: " + err.Error())
-	}
-
-	rows, err := srcFile.GetRows(sheet)
-	if err != nil {
-		return nil, err
-	}
-
-	for rowIdx, row := range rows {
-		for colIdx, val := range row {
-			colNum := colIdx + 1
-			rowNum := rowIdx + 1
-			cellRef, _ := excelize.CoordinatesToCellName(colNum, rowNum)
-
-			styleID, _ := srcFile.GetCellStyle(sheet, cellRef)
-			styleJSON, _ := srcFile.GetStyle(styleID) // get full style definition
-
-			footer = append(footer, FooterCell{
-				Row:   rowNum,
-				Col:   colNum,
-				Value: val,
-				Style: styleJSON,
-			})
-		}
-	}
-
-	return footer, nil
-}
-
-// ApplyFooterToSheet inserts the prepared footer into a new file
-func ApplyFooterToSheet(dstFile *excelize.File, dstSheet string, footer []FooterCell, rowOffset int) error {
-	for _, cell := range footer {
-		cellName, _ := excelize.CoordinatesToCellName(cell.Col, cell.Row+rowOffset)
-		if err := dstFile.SetCellValue(dstSheet, cellName, cell.Value); err != nil {
-			return err
-		}
-
-		if cell.Style != nil {
-			styleID, err := dstFile.NewStyle(cell.Style)
-			if err != nil {
-				return err
-			}
-			if err := dstFile.SetCellStyle(dstSheet, cellName, cellName, styleID); err != nil {
-				return err
-			}
-		}
-	}
-
-	return nil
-}
-
-/*
-================================================================================
-Only Styles below this line
-================================================================================
-*/
-func setStyles(f *excelize.File) error {
-	var err error
-	// TITLE ROW
-	styleTitle, err = f.NewStyle(&excelize.Style{
-		Fill: excelize.Fill{
-			Type:    "pattern",
-			Color:   []string{"#AAAAAA"},
-			Pattern: 1,
-		},
-		Border: []excelize.Border{
-			{Type: "left", Color: "000000", Style: 1},
-			{Type: "top", Color: "000000", Style: 1},
-			{Type: "right", Color: "000000", Style: 1},
-			{Type: "bottom", Color: "000000", Style: 1},
-		},
-		Font: &excelize.Font{
-			Bold:  true,
-			Size:  20,
-			Color: "FFFFFF",
-		},
-		Alignment: &excelize.Alignment{
-			Horizontal: "center",
-		},
-	})
-	if err != nil {
-		return errors.New("Failed to create styleTitle: " + err.Error())
-	}
-	// HEADER ROW
-	styleHeader, err = f.NewStyle(&excelize.Style{
-		Fill: excelize.Fill{
-			Type:    "pattern",
-			Color:   []string{"#A1C2F1"},
-			Pattern: 1,
-		},
-		Border: []excelize.Border{
-			{Type: "left", Color: "000000", Style: 1},
-			{Type: "top", Color: "000000", Style: 1},
-			{Type: "right", Color: "000000", Style: 1},
-			{Type: "bottom", Color: "000000", Style: 1},
-		},
-		Font: &excelize.Font{
-			Bold: true,
-		},
-		Alignment: &excelize.Alignment{
-			Horizontal: "center",
-		},
-	})
-	if err != nil {
-		return errors.New("Failed to create styleName: " + err.Error())
-	}
-	// NAME COLUMN
-	styleName, err = f.NewStyle(&excelize.Style{
-		Fill: excelize.Fill{
-			Type:    "pattern",
-			Color:   []string{"#F4D793"},
-			Pattern: 1,
-		},
-		Border: []excelize.Border{
-			{Type: "left", Color: "000000", Style: 1},
-			{Type: "top", Color: "000000", Style: 1},
-			{Type: "right", Color: "000000", Style: 1},
-			{Type: "bottom", Color: "000000", Style: 1},
-		},
-	})
-	if err != nil {
-		return errors.New("Failed to create styleName: " + err.Error())
-	}
-	// HOUR CELLS (depending on activity)
-	styleFree, err = f.NewStyle(&excelize.Style{
-		Fill: excelize.Fill{
-			Type:    "pattern",
-			Color:   []string{"#B4B4B8"},
-			Pattern: 1,
-		},
-		Border: []excelize.Border{
-			{Type: "left", Color: "000000", Style: 1},
-			{Type: "top", Color: "000000", Style: 1},
-			{Type: "right", Color: "000000", Style: 1},
-			{Type: "bottom", Color: "000000", Style: 1},
-		},
-		Alignment: &excelize.Alignment{
-			Horizontal: "center",
-		},
-	})
-	if err != nil {
-		return errors.New("Failed to create styleFree: " + err.Error())
-	}
-	styleWork, err = f.NewStyle(&excelize.Style{
-		Fill: excelize.Fill{
-			Type:    "pattern",
-			Color:   []string{"#FFFFFF"},
-			Pattern: 1,
-		},
-		Border: []excelize.Border{
-			{Type: "left", Color: "000000", Style: 1},
-			{Type: "top", Color: "000000", Style: 1},
-			{Type: "right", Color: "000000", Style: 1},
-			{Type: "bottom", Color: "000000", Style: 1},
-		},
-		Alignment: &excelize.Alignment{
-			Horizontal: "center",
-		},
-	})
-	if err != nil {
-		return errors.New("Failed to create styleWork: " + err.Error())
-	}
-	styleLunch, err = f.NewStyle(&excelize.Style{
-		Fill: excelize.Fill{
-			Type:    "pattern",
-			Color:   []string{"#F6EFBD"},
-			Pattern: 1,
-		},
-		Border: []excelize.Border{
-			{Type: "left", Color: "000000", Style: 1},
-			{Type: "top", Color: "000000", Style: 1},
-			{Type: "right", Color: "000000", Style: 1},
-			{Type: "bottom", Color: "000000", Style: 1},
-		},
-		Alignment: &excelize.Alignment{
-			Horizontal: "center",
-		},
-	})
-	if err != nil {
-		return errors.New("Failed to create styleLunch: " + err.Error())
-	}
-	styleAssigned, err = f.NewStyle(&excelize.Style{
-		Fill: excelize.Fill{
-			Type:    "pattern",
-			Color:   []string{"#FFFFFF"},
-			Pattern: 1,
-		},
-		Border: []excelize.Border{
-			{Type: "left", Color: "000000", Style: 1},
-			{Type: "top", Color: "000000", Style: 1},
-			{Type: "right", Color: "000000", Style: 1},
-			{Type: "bottom", Color: "000000", Style: 1},
-		},
-		Alignment: &excelize.Alignment{
-			Horizontal: "center",
-		},
-	})
-	if err != nil {
-		return errors.New("Failed to create styleAssigned: " + err.Error())
-	}
-
-	return nil
-}
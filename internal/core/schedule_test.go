@@ -38,7 +38,7 @@ func TestFixEmployeeId(t *testing.T) {
 
 func TestExtractShiftDetails(t *testing.T) {
 	s := series.New([]string{"09:00 - 17:00", "08:30 - 12:00"}, series.String, "time")
-	out, err := extractShiftDetails(s)
+	out, err := extractShiftDetails(s, DefaultProcessOptions())
 	if err != nil {
 		t.Fatalf("unexpected error: %v", err)
 	}
@@ -118,13 +118,13 @@ func TestPrepareAndApplyFooter(t *testing.T) {
 	if err != nil {
 		t.Fatalf("PrepareFooter error: %v", err)
 	}
-	if len(footer) == 0 {
+	if len(footer.Cells) == 0 {
 		t.Fatalf("expected footer cells, got 0")
 	}
 
 	dst := excelize.NewFile()
 	dst.NewSheet("Sheet1")
-	if err := ApplyFooterToSheet(dst, "Sheet1", footer, 1); err != nil {
+	if err := ApplyFooterToSheet(dst, "Sheet1", footer, 1, nil); err != nil {
 		t.Fatalf("ApplyFooterToSheet error: %v", err)
 	}
 
@@ -161,3 +161,64 @@ func TestReadSettingsFile(t *testing.T) {
 	// DataFrame or an empty one (no error); ensure the call succeeds.
 	_ = df
 }
+
+// buildInputFile creates an input workbook in the export format
+// (employeeId, lastName, firstName, shiftType, date, time, department).
+// Like the real export, the first row is a title and the second the header.
+func buildInputFile(t *testing.T, shifts [][]string) *bytes.Reader {
+	t.Helper()
+	f := excelize.NewFile()
+	defer f.Close()
+	f.SetSheetName("Sheet1", "Worksheet")
+	f.SetSheetRow("Worksheet", "A1", &[]interface{}{"Export"})
+	f.SetSheetRow("Worksheet", "A2", &[]interface{}{"Id", "Efternamn", "Förnamn", "Typ", "Datum", "Tid", "Avdelning"})
+	for i, shift := range shifts {
+		row := make([]interface{}, len(shift))
+		for j, v := range shift {
+			row[j] = v
+		}
+		cell, _ := excelize.CoordinatesToCellName(1, i+3)
+		f.SetSheetRow("Worksheet", cell, &row)
+	}
+	buf, err := f.WriteToBuffer()
+	if err != nil {
+		t.Fatalf("failed writing input buffer: %v", err)
+	}
+	return bytes.NewReader(buf.Bytes())
+}
+
+func TestProcessFilesWithOptions(t *testing.T) {
+	input := buildInputFile(t, [][]string{
+		{"1", "Svensson", "Anna", "Pass", "2025-03-17", "09:00 - 17:00", "Kassa"},
+		{"2", "Berg", "Erik", "Pass", "2025-03-17", "12:00 - 16:00", "Kassa"},
+		{"1", "Svensson", "Anna", "Pass", "2025-03-24", "10:00 - 14:00", "Kassa"},
+	})
+	opts := DefaultProcessOptions()
+	opts.FileNamePattern = "{{year}}-v{{week}}"
+	opts.NameHeader = "Name"
+
+	result, err := ProcessFilesWithOptions(input, nil, nil, opts)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(result) != 2 {
+		t.Fatalf("expected 2 week files, got %d", len(result))
+	}
+	content, ok := result["2025-v12.xlsx"]
+	if !ok {
+		t.Fatalf("expected 2025-v12.xlsx in result, got %v", result)
+	}
+	f, err := excelize.OpenReader(bytes.NewReader(content))
+	if err != nil {
+		t.Fatalf("opening result: %v", err)
+	}
+	defer f.Close()
+	header, _ := f.GetCellValue("Monday", "B2")
+	if header != "Name" {
+		t.Fatalf("expected configured name header, got %q", header)
+	}
+	name, _ := f.GetCellValue("Monday", "B3")
+	if name != "Anna Svensson" {
+		t.Fatalf("expected first shift for Anna Svensson, got %q", name)
+	}
+}
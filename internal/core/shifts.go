@@ -0,0 +1,144 @@
+package core
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"sort"
+	"strconv"
+
+	"github.com/go-gota/gota/dataframe"
+)
+
+// Shift is one refined row from the input export
+type Shift struct {
+	EmployeeId  int     `json:"employeeId"`
+	FirstName   string  `json:"firstName"`
+	LastName    string  `json:"lastName"`
+	ShiftType   string  `json:"shiftType"`
+	Department  string  `json:"department"`
+	Date        string  `json:"date"`
+	WeekNumber  int     `json:"weekNumber"`
+	Time        string  `json:"time"`
+	StartTime   string  `json:"startTime"`
+	EndTime     string  `json:"endTime"`
+	ShiftLength float64 `json:"shiftLength"`
+	HasLunch    bool    `json:"hasLunch"`
+	Role        string  `json:"role"`
+	Phone       string  `json:"phone"`
+	// From the settings file, kept out of the exports
+	HourlyWage float64 `json:"-"`
+}
+
+// Name returns "first last" as shown on the day sheets
+func (s Shift) Name() string {
+	return s.FirstName + " " + s.LastName
+}
+
+// ValidationReport summarises an input file without rendering it
+type ValidationReport struct {
+	Shifts    int      `json:"shifts"`
+	Employees int      `json:"employees"`
+	Weeks     []int    `json:"weeks"`
+	Warnings  []string `json:"warnings"`
+	Errors    []string `json:"errors"`
+}
+
+// OK reports whether the input can be rendered
+func (r ValidationReport) OK() bool {
+	return len(r.Errors) == 0
+}
+
+// ReadShifts reads and refines the input (and optional settings) into shifts
+func ReadShifts(input io.Reader, settings io.Reader, opts ProcessOptions) ([]Shift, error) {
+	if err := opts.Validate(); err != nil {
+		return nil, errors.New("Invalid options: " + err.Error())
+	}
+	settingsDf, _ := readSettingsFile(settings)
+	df, err := readAndRefineInputData(input, settingsDf, opts)
+	if err != nil {
+		return nil, errors.New("Error reading input data: " + err.Error())
+	}
+	return shiftsFromDataFrame(df)
+}
+
+// ValidateInput reads the input like ProcessFiles would and reports problems
+func ValidateInput(input io.Reader, settings io.Reader, opts ProcessOptions) ValidationReport {
+	report := ValidationReport{}
+	shifts, err := ReadShifts(input, settings, opts)
+	if err != nil {
+		report.Errors = append(report.Errors, err.Error())
+		return report
+	}
+	if len(shifts) == 0 {
+		report.Errors = append(report.Errors, "input contains no shifts")
+		return report
+	}
+
+	employees := map[int]bool{}
+	weeks := map[int]bool{}
+	seen := map[string]bool{}
+	for _, s := range shifts {
+		employees[s.EmployeeId] = true
+		weeks[s.WeekNumber] = true
+		if s.EmployeeId < 0 {
+			report.Warnings = append(report.Warnings, fmt.Sprintf("%s %s: %s has no valid employee id", s.Date, s.Time, s.Name()))
+		}
+		if s.EndTime <= s.StartTime {
+			report.Errors = append(report.Errors, fmt.Sprintf("%s %s: %s ends before it starts", s.Date, s.Time, s.Name()))
+		}
+		if settings != nil && s.Role == "" && s.Phone == "" {
+			report.Warnings = append(report.Warnings, fmt.Sprintf("%s %s: %s has no settings row", s.Date, s.Time, s.Name()))
+		}
+		key := fmt.Sprintf("%d|%s|%s", s.EmployeeId, s.Date, s.Time)
+		if seen[key] {
+			report.Warnings = append(report.Warnings, fmt.Sprintf("%s %s: duplicate shift for %s", s.Date, s.Time, s.Name()))
+		}
+		seen[key] = true
+	}
+	report.Shifts = len(shifts)
+	report.Employees = len(employees)
+	for w := range weeks {
+		report.Weeks = append(report.Weeks, w)
+	}
+	sort.Ints(report.Weeks)
+
+	return report
+}
+
+// Convert a refined dataframe into shifts
+func shiftsFromDataFrame(df dataframe.DataFrame) ([]Shift, error) {
+	shifts := make([]Shift, df.Nrow())
+	for i := 0; i < df.Nrow(); i++ {
+		employeeId, err := strconv.Atoi(df.Col("employeeId").Elem(i).String())
+		if err != nil {
+			return nil, errors.New("Error parsing employeeId: " + err.Error())
+		}
+		weekNumber, err := strconv.Atoi(df.Col("weekNumber").Elem(i).String())
+		if err != nil {
+			return nil, errors.New("Error parsing weekNumber: " + err.Error())
+		}
+		hasLunch, err := df.Col("hasLunch").Elem(i).Bool()
+		if err != nil {
+			return nil, errors.New("Error parsing hasLunch: " + err.Error())
+		}
+		shifts[i] = Shift{
+			EmployeeId:  employeeId,
+			FirstName:   df.Col("firstName").Elem(i).String(),
+			LastName:    df.Col("lastName").Elem(i).String(),
+			ShiftType:   df.Col("shiftType").Elem(i).String(),
+			Department:  df.Col("department").Elem(i).String(),
+			Date:        df.Col("date").Elem(i).String(),
+			WeekNumber:  weekNumber,
+			Time:        df.Col("time").Elem(i).String(),
+			StartTime:   df.Col("startTime").Elem(i).String(),
+			EndTime:     df.Col("endTime").Elem(i).String(),
+			ShiftLength: df.Col("shiftLength").Elem(i).Float(),
+			HasLunch:    hasLunch,
+			Role:        df.Col("role").Elem(i).String(),
+			Phone:       df.Col("phone").Elem(i).String(),
+			HourlyWage:  df.Col("hourlyWage").Elem(i).Float(),
+		}
+	}
+	return shifts, nil
+}
@@ -0,0 +1,103 @@
+package core
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/xuri/excelize/v2"
+)
+
+/*
+================================================================================
+Read simple tables (xlsx or CSV) used by the optional data sources
+================================================================================
+*/
+
+// readTable returns the rows of the active sheet of an xlsx file, or of a
+// CSV file separated by comma, semicolon or tab. The format is sniffed
+// from the content since uploads do not always carry a file name.
+func readTable(r io.Reader) ([][]string, error) {
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return nil, err
+	}
+	// xlsx files are zip archives
+	if bytes.HasPrefix(data, []byte("PK")) {
+		f, err := excelize.OpenReader(bytes.NewReader(data))
+		if err != nil {
+			return nil, errors.New("Error opening file: " + err.Error())
+		}
+		defer f.Close()
+		return f.GetRows(f.GetSheetName(f.GetActiveSheetIndex()))
+	}
+
+	data = bytes.TrimPrefix(data, []byte("\xef\xbb\xbf")) // UTF-8 BOM from Excel
+	firstLine, _ := bufio.NewReader(bytes.NewReader(data)).ReadString('\n')
+	reader := csv.NewReader(bytes.NewReader(data))
+	reader.FieldsPerRecord = -1
+	reader.Comma = ','
+	for _, sep := range []rune{';', '\t'} {
+		if strings.Count(firstLine, string(sep)) > strings.Count(firstLine, string(reader.Comma)) {
+			reader.Comma = sep
+		}
+	}
+	rows, err := reader.ReadAll()
+	if err != nil {
+		return nil, errors.New("Error reading CSV: " + err.Error())
+	}
+	return rows, nil
+}
+
+// tableRecords maps each data row to its header names (lower case)
+func tableRecords(rows [][]string) []map[string]string {
+	if len(rows) < 2 {
+		return nil
+	}
+	headers := make([]string, len(rows[0]))
+	for i, h := range rows[0] {
+		headers[i] = strings.ToLower(strings.TrimSpace(h))
+	}
+	records := []map[string]string{}
+	for _, row := range rows[1:] {
+		record := map[string]string{}
+		empty := true
+		for i, v := range row {
+			if i < len(headers) && headers[i] != "" {
+				record[headers[i]] = strings.TrimSpace(v)
+				empty = empty && strings.TrimSpace(v) == ""
+			}
+		}
+		if !empty {
+			records = append(records, record)
+		}
+	}
+	return records
+}
+
+// Date layouts accepted in data sources, Excel shows dates as 01-02-06 by default
+var dateLayouts = []string{time.DateOnly, "2006/01/02", "01-02-06", "1/2/06", "02.01.2006", "20060102"}
+
+// normalizeDate returns the date as YYYY-MM-DD
+func normalizeDate(s string) (string, error) {
+	s = strings.TrimSpace(s)
+	for _, layout := range dateLayouts {
+		if t, err := time.Parse(layout, s); err == nil {
+			return t.Format(time.DateOnly), nil
+		}
+	}
+	// Excel serial date number
+	if serial, err := strconv.ParseFloat(s, 64); err == nil && serial > 0 {
+		t, err := excelize.ExcelDateToTime(serial, false)
+		if err == nil {
+			return t.Format(time.DateOnly), nil
+		}
+	}
+	return "", fmt.Errorf("invalid date %q", s)
+}
@@ -0,0 +1,215 @@
+package core
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/xuri/excelize/v2"
+)
+
+// Placeholders recognised in the prototype day sheet of a template
+const (
+	placeholderTitle    = "{{title}}"
+	placeholderHeader   = "{{header}}"
+	placeholderShiftRow = "{{shiftRow}}"
+	placeholderFooter   = "{{footer}}"
+	placeholderLegend   = "{{legend}}"
+	placeholderNotes    = "{{notes}}"
+)
+
+// templatePicture is a picture anchored in the prototype sheet
+type templatePicture struct {
+	col     int
+	row     int
+	picture excelize.Picture
+}
+
+// dayTemplate is a prototype day sheet copied for every day
+type dayTemplate struct {
+	data       []byte // template workbook, reopened for every week
+	sheet      string // name of the prototype sheet
+	layout     dayLayout
+	pictures   []templatePicture
+	pageLayout excelize.PageLayoutOptions
+}
+
+/*
+================================================================================
+Read the layout template and locate the placeholders
+================================================================================
+*/
+func prepareTemplate(r io.Reader, sheet string) (*dayTemplate, error) {
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return nil, errors.New("Error reading template file: " + err.Error())
+	}
+	f, err := excelize.OpenReader(bytes.NewReader(data))
+	if err != nil {
+		return nil, errors.New("Error opening template file: " + err.Error())
+	}
+	defer f.Close()
+
+	// Use the named prototype sheet, or the first sheet
+	if idx, _ := f.GetSheetIndex(sheet); sheet == "" || idx < 0 {
+		sheet = f.GetSheetList()[0]
+	}
+	t := &dayTemplate{data: data, sheet: sheet, layout: dayLayout{templated: true}}
+
+	rows, err := f.GetRows(sheet)
+	if err != nil {
+		return nil, errors.New("Error reading template rows: " + err.Error())
+	}
+	for rowIdx, row := range rows {
+		for colIdx, val := range row {
+			cell, _ := excelize.CoordinatesToCellName(colIdx+1, rowIdx+1)
+			style, _ := f.GetCellStyle(sheet, cell)
+			switch strings.TrimSpace(val) {
+			case placeholderTitle:
+				t.layout.titleRow, t.layout.titleCol, t.layout.titleStyle = rowIdx+1, colIdx+1, style
+			case placeholderHeader:
+				t.layout.headerRow, t.layout.headerStyle = rowIdx+1, style
+			case placeholderShiftRow:
+				t.layout.shiftRow, t.layout.gridCol = rowIdx+1, colIdx+1
+			case placeholderFooter:
+				t.layout.footerRow = rowIdx + 1
+			case placeholderLegend:
+				t.layout.legendRow = rowIdx + 1
+			case placeholderNotes:
+				t.layout.notesRow = rowIdx + 1
+			}
+		}
+	}
+	if t.layout.shiftRow == 0 {
+		return nil, fmt.Errorf("template sheet %q has no %s placeholder", sheet, placeholderShiftRow)
+	}
+	if t.layout.headerRow == 0 || t.layout.titleRow == 0 {
+		return nil, fmt.Errorf("template sheet %q needs %s and %s placeholders", sheet, placeholderTitle, placeholderHeader)
+	}
+	if t.layout.headerRow >= t.layout.shiftRow ||
+		(t.layout.footerRow != 0 && t.layout.footerRow <= t.layout.shiftRow) ||
+		(t.layout.legendRow != 0 && t.layout.legendRow <= t.layout.shiftRow) ||
+		(t.layout.notesRow != 0 && t.layout.notesRow <= t.layout.shiftRow) {
+		return nil, fmt.Errorf("template placeholders must be ordered title, header, shiftRow, legend/notes/footer")
+	}
+
+	// Styles of the shift row columns (time, name, phone)
+	for i, style := range []*int{&t.layout.timeStyle, &t.layout.nameStyle, &t.layout.phoneStyle} {
+		cell, _ := excelize.CoordinatesToCellName(t.layout.gridCol+i, t.layout.shiftRow)
+		*style, _ = f.GetCellStyle(sheet, cell)
+	}
+
+	// Pictures and page layout are not carried over by CopySheet
+	cells, err := f.GetPictureCells(sheet)
+	if err != nil {
+		return nil, errors.New("Error reading template pictures: " + err.Error())
+	}
+	for _, cell := range cells {
+		col, row, _ := excelize.CellNameToCoordinates(cell)
+		pics, _ := f.GetPictures(sheet, cell)
+		for _, pic := range pics {
+			t.pictures = append(t.pictures, templatePicture{col: col, row: row, picture: pic})
+		}
+	}
+	t.pageLayout, _ = f.GetPageLayout(sheet)
+
+	return t, nil
+}
+
+// newWorkbook opens a fresh copy of the template workbook
+func (t *dayTemplate) newWorkbook() (*excelize.File, error) {
+	return excelize.OpenReader(bytes.NewReader(t.data))
+}
+
+// newDaySheet copies the prototype into a new sheet with room for the given
+// number of shifts, rows under the trailing header (cost, staffing) and notes
+// and returns the layout adjusted for the inserted rows
+func (t *dayTemplate) newDaySheet(f *excelize.File, name string, shifts int, extra int, notes int) (dayLayout, error) {
+	layout := t.layout
+	protoIdx, err := f.GetSheetIndex(t.sheet)
+	if err != nil {
+		return layout, err
+	}
+	idx, err := f.NewSheet(name)
+	if err != nil {
+		return layout, err
+	}
+	if err := f.CopySheet(protoIdx, idx); err != nil {
+		return layout, err
+	}
+	if err := f.SetPageLayout(name, &t.pageLayout); err != nil {
+		return layout, err
+	}
+
+	// Clear placeholder cells
+	for _, placeholder := range []string{placeholderTitle, placeholderHeader, placeholderShiftRow, placeholderFooter, placeholderLegend, placeholderNotes} {
+		cells, _ := f.SearchSheet(name, placeholder)
+		for _, cell := range cells {
+			f.SetCellValue(name, cell, nil)
+		}
+	}
+
+	// Make room for every shift, the trailing header and the rows under it,
+	// the placeholder row is the first shift
+	inserted := shifts + extra
+	if err := f.InsertRows(name, layout.shiftRow+1, inserted); err != nil {
+		return layout, err
+	}
+	if layout.footerRow != 0 {
+		layout.footerRow += inserted
+	}
+	if layout.legendRow != 0 {
+		layout.legendRow += inserted
+	}
+	if layout.notesRow != 0 {
+		layout.notesRow += inserted
+	}
+
+	// The notes placeholder row holds the box title, one row is added per note
+	if layout.notesRow != 0 && notes > 0 {
+		if err := f.InsertRows(name, layout.notesRow+1, notes); err != nil {
+			return layout, err
+		}
+		if layout.footerRow > layout.notesRow {
+			layout.footerRow += notes
+		}
+		if layout.legendRow > layout.notesRow {
+			layout.legendRow += notes
+		}
+	}
+
+	for _, p := range t.pictures {
+		row := p.row
+		if row > t.layout.shiftRow {
+			row += inserted
+		}
+		if t.layout.notesRow != 0 && p.row > t.layout.notesRow {
+			row += notes
+		}
+		cell, _ := excelize.CoordinatesToCellName(p.col, row)
+		pic := p.picture
+		if err := f.AddPictureFromBytes(name, cell, &pic); err != nil {
+			return layout, err
+		}
+	}
+
+	return layout, nil
+}
+
+func isMerged(f *excelize.File, sheet string, cell string) bool {
+	merged, err := f.GetMergeCells(sheet)
+	if err != nil {
+		return false
+	}
+	col, row, _ := excelize.CellNameToCoordinates(cell)
+	for _, m := range merged {
+		startCol, startRow, _ := excelize.CellNameToCoordinates(m.GetStartAxis())
+		endCol, endRow, _ := excelize.CellNameToCoordinates(m.GetEndAxis())
+		if col >= startCol && col <= endCol && row >= startRow && row <= endRow {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,153 @@
+package core
+
+import (
+	"bytes"
+	"image"
+	"image/png"
+	"testing"
+
+	"github.com/xuri/excelize/v2"
+)
+
+func buildTemplateFile(t *testing.T) *bytes.Reader {
+	t.Helper()
+	f := excelize.NewFile()
+	defer f.Close()
+	f.SetSheetName("Sheet1", "Day")
+	f.SetCellValue("Day", "B2", "{{title}}")
+	f.SetCellValue("Day", "B3", "{{header}}")
+	f.SetCellValue("Day", "B4", "{{shiftRow}}")
+	f.SetCellValue("Day", "B6", "{{footer}}")
+	f.SetCellValue("Day", "A8", "Static text")
+	f.SetColWidth("Day", "C", "C", 42)
+	headerStyle, _ := f.NewStyle(&excelize.Style{Font: &excelize.Font{Italic: true}})
+	f.SetCellStyle("Day", "B3", "B3", headerStyle)
+	orientation := "landscape"
+	f.SetPageLayout("Day", &excelize.PageLayoutOptions{Orientation: &orientation})
+
+	var logo bytes.Buffer
+	png.Encode(&logo, image.NewRGBA(image.Rect(0, 0, 4, 4)))
+	f.AddPictureFromBytes("Day", "A1", &excelize.Picture{Extension: ".png", File: logo.Bytes()})
+
+	buf, err := f.WriteToBuffer()
+	if err != nil {
+		t.Fatalf("failed writing template buffer: %v", err)
+	}
+	return bytes.NewReader(buf.Bytes())
+}
+
+func TestPrepareTemplate(t *testing.T) {
+	tmpl, err := prepareTemplate(buildTemplateFile(t), "Day")
+	if err != nil {
+		t.Fatalf("prepareTemplate error: %v", err)
+	}
+	l := tmpl.layout
+	if l.titleRow != 2 || l.titleCol != 2 || l.headerRow != 3 || l.shiftRow != 4 || l.gridCol != 2 || l.footerRow != 6 {
+		t.Fatalf("unexpected layout: %+v", l)
+	}
+	if l.headerStyle == 0 {
+		t.Fatalf("expected header style from template")
+	}
+	if len(tmpl.pictures) != 1 {
+		t.Fatalf("expected template logo, got %d pictures", len(tmpl.pictures))
+	}
+}
+
+func TestPrepareTemplateMissingPlaceholder(t *testing.T) {
+	f := excelize.NewFile()
+	f.SetCellValue("Sheet1", "A1", "{{title}}")
+	buf, _ := f.WriteToBuffer()
+	if _, err := prepareTemplate(bytes.NewReader(buf.Bytes()), "Day"); err == nil {
+		t.Fatalf("expected error for template without shiftRow")
+	}
+}
+
+func TestProcessFilesWithTemplate(t *testing.T) {
+	input := buildInputFile(t, [][]string{
+		{"1", "Svensson", "Anna", "Pass", "2025-03-17", "09:00 - 17:00", "Kassa"},
+		{"2", "Berg", "Erik", "Pass", "2025-03-17", "12:00 - 16:00", "Kassa"},
+	})
+	footer := excelize.NewFile()
+	footer.SetSheetName("Sheet1", "Footer")
+	footer.SetCellValue("Footer", "A1", "FooterText")
+	footerBuf, _ := footer.WriteToBuffer()
+
+	opts := DefaultProcessOptions()
+	opts.Template = buildTemplateFile(t)
+	result, err := ProcessFilesWithOptions(input, nil, bytes.NewReader(footerBuf.Bytes()), opts)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	f, err := excelize.OpenReader(bytes.NewReader(result["Vecka 12.xlsx"]))
+	if err != nil {
+		t.Fatalf("opening result: %v", err)
+	}
+	defer f.Close()
+
+	if sheets := f.GetSheetList(); len(sheets) != 1 || sheets[0] != "Monday" {
+		t.Fatalf("expected only the Monday sheet, got %v", sheets)
+	}
+	expect := map[string]string{
+		"B2":  "Monday - 2025-03-17",
+		"B3":  "Arbetstid",
+		"C4":  "Anna Svensson",
+		"C5":  "Erik Berg",
+		"C6":  "Namn",       // trailing header
+		"A8":  "FooterText", // footer placed at the {{footer}} row
+		"A10": "Static text",
+	}
+	for cell, want := range expect {
+		if got, _ := f.GetCellValue("Monday", cell); got != want {
+			t.Fatalf("expected %q in %s, got %q", want, cell, got)
+		}
+	}
+	if width, _ := f.GetColWidth("Monday", "C"); width != 42 {
+		t.Fatalf("expected template column width, got %v", width)
+	}
+	if layout, _ := f.GetPageLayout("Monday"); layout.Orientation == nil || *layout.Orientation != "landscape" {
+		t.Fatalf("expected landscape orientation from template")
+	}
+	if cells, _ := f.GetPictureCells("Monday"); len(cells) != 1 {
+		t.Fatalf("expected template logo on day sheet, got %v", cells)
+	}
+}
+
+func TestProcessFilesWithTemplateCostAndDemand(t *testing.T) {
+	input := buildInputFile(t, [][]string{
+		{"1", "Svensson", "Anna", "Pass", "2025-03-17", "09:00 - 17:00", "Kassa"},
+		{"2", "Berg", "Erik", "Pass", "2025-03-17", "12:00 - 16:00", "Kassa"},
+	})
+	footer := excelize.NewFile()
+	footer.SetSheetName("Sheet1", "Footer")
+	footer.SetCellValue("Footer", "A1", "FooterText")
+	footerBuf, _ := footer.WriteToBuffer()
+
+	opts := DefaultProcessOptions()
+	opts.Template = buildTemplateFile(t)
+	opts.Cost.Enabled = true
+	opts.Cost.DefaultWage = 100
+	opts.Demand = bytes.NewReader([]byte("date,slot,required\n2025-03-17,10:00,2\n"))
+	result, err := ProcessFilesWithOptions(input, nil, bytes.NewReader(footerBuf.Bytes()), opts)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	f, err := excelize.OpenReader(bytes.NewReader(result["Vecka 12.xlsx"]))
+	if err != nil {
+		t.Fatalf("opening result: %v", err)
+	}
+	defer f.Close()
+
+	// The cost and staffing rows push the footer and static text down
+	expect := map[string]string{
+		"C6":  "Namn",
+		"B7":  "Kostnad",
+		"B8":  "Bemanning",
+		"A10": "FooterText",
+		"A12": "Static text",
+	}
+	for cell, want := range expect {
+		if got, _ := f.GetCellValue("Monday", cell); got != want {
+			t.Fatalf("expected %q in %s, got %q", want, cell, got)
+		}
+	}
+}
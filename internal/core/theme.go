@@ -0,0 +1,395 @@
+package core
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+
+	"github.com/xuri/excelize/v2"
+)
+
+// StyleDef describes the look of one kind of cell. Empty fields keep the
+// value from the palette, so a config only needs to list what it changes.
+type StyleDef struct {
+	Fill        string  `json:"fill" toml:"fill" yaml:"fill"`                      // fill colour, e.g. "#F6EFBD"
+	Pattern     int     `json:"pattern" toml:"pattern" yaml:"pattern"`             // excel fill pattern, 1 is solid
+	FontColor   string  `json:"fontColor" toml:"fontColor" yaml:"fontColor"`       // e.g. "FFFFFF"
+	FontSize    float64 `json:"fontSize" toml:"fontSize" yaml:"fontSize"`          // points
+	Bold        bool    `json:"bold" toml:"bold" yaml:"bold"`                      // only switches bold on
+	Italic      bool    `json:"italic" toml:"italic" yaml:"italic"`                // only switches italic on
+	Strike      bool    `json:"strike" toml:"strike" yaml:"strike"`                // only switches strike-through on
+	Border      string  `json:"border" toml:"border" yaml:"border"`                // none, thin, medium, thick, dashed or dotted
+	BorderColor string  `json:"borderColor" toml:"borderColor" yaml:"borderColor"` // e.g. "000000"
+	Label       string  `json:"label" toml:"label" yaml:"label"`                   // text in the legend
+}
+
+// ThemeOptions selects a palette and overrides single styles
+type ThemeOptions struct {
+	// Base palette: default, colorblind or print
+	Palette string `json:"palette" toml:"palette" yaml:"palette"`
+	// Render a legend explaining the colours on each day sheet
+	Legend      bool   `json:"legend" toml:"legend" yaml:"legend"`
+	LegendTitle string `json:"legendTitle" toml:"legendTitle" yaml:"legendTitle"`
+	// Title of the daily notes box
+	NotesTitle string `json:"notesTitle" toml:"notesTitle" yaml:"notesTitle"`
+	// Title of the absences list
+	AbsencesTitle string `json:"absencesTitle" toml:"absencesTitle" yaml:"absencesTitle"`
+
+	Title    StyleDef `json:"title" toml:"title" yaml:"title"`
+	Holiday  StyleDef `json:"holiday" toml:"holiday" yaml:"holiday"` // title and overview header on holidays
+	Header   StyleDef `json:"header" toml:"header" yaml:"header"`
+	Name     StyleDef `json:"name" toml:"name" yaml:"name"`
+	Free     StyleDef `json:"free" toml:"free" yaml:"free"`
+	Work     StyleDef `json:"work" toml:"work" yaml:"work"`
+	Lunch    StyleDef `json:"lunch" toml:"lunch" yaml:"lunch"`
+	Assigned StyleDef `json:"assigned" toml:"assigned" yaml:"assigned"`
+	Note     StyleDef `json:"note" toml:"note" yaml:"note"`
+	NoteHigh StyleDef `json:"noteHigh" toml:"noteHigh" yaml:"noteHigh"` // high priority notes
+	// Names of shifts taken by an absence, wholly or in part
+	Absent        StyleDef `json:"absent" toml:"absent" yaml:"absent"`
+	AbsentPartial StyleDef `json:"absentPartial" toml:"absentPartial" yaml:"absentPartial"`
+	// Hour cells of the planned against actual comparison
+	Late    StyleDef `json:"late" toml:"late" yaml:"late"`
+	Early   StyleDef `json:"early" toml:"early" yaml:"early"`
+	Missing StyleDef `json:"missing" toml:"missing" yaml:"missing"`
+	Extra   StyleDef `json:"extra" toml:"extra" yaml:"extra"`
+	// Time cells of shifts changed since the previous export
+	Changed StyleDef `json:"changed" toml:"changed" yaml:"changed"`
+	// Styles for assigned hours per role, keyed by role name
+	Roles map[string]StyleDef `json:"roles" toml:"roles" yaml:"roles"`
+}
+
+// sheetStyles are the style ids created in one workbook
+type sheetStyles struct {
+	title    int
+	holiday  int
+	header   int
+	name     int
+	free     int
+	work     int
+	lunch    int
+	assigned int
+	note     int
+	noteHigh int
+	absent   int
+	// Name cell of a shift partly taken by an absence
+	absentPartial int
+	late          int
+	early         int
+	missing       int
+	extra         int
+	changed       int
+	roles         map[string]int
+}
+
+// activity returns the style for an hour cell
+func (s sheetStyles) activity(state HourActivity, role string) int {
+	switch state {
+	case StateWork:
+		return s.work
+	case StateLunch:
+		return s.lunch
+	case StateAssigned:
+		if id, ok := s.roles[role]; ok {
+			return id
+		}
+		return s.assigned
+	}
+	return s.free
+}
+
+var palettes = map[string]ThemeOptions{
+	// The original colours
+	"default": {
+		LegendTitle:   "Förklaring",
+		Title:         StyleDef{Fill: "#AAAAAA", Pattern: 1, FontColor: "FFFFFF", FontSize: 20, Bold: true, Border: "thin", BorderColor: "000000"},
+		Holiday:       StyleDef{Fill: "#C00000", Pattern: 1, FontColor: "FFFFFF", FontSize: 20, Bold: true, Border: "thin", BorderColor: "000000"},
+		Header:        StyleDef{Fill: "#A1C2F1", Pattern: 1, Bold: true, Border: "thin", BorderColor: "000000"},
+		Name:          StyleDef{Fill: "#F4D793", Pattern: 1, Border: "thin", BorderColor: "000000"},
+		Free:          StyleDef{Fill: "#B4B4B8", Pattern: 1, Border: "thin", BorderColor: "000000", Label: "Ledig"},
+		Work:          StyleDef{Fill: "#FFFFFF", Pattern: 1, Border: "thin", BorderColor: "000000", Label: "Arbete"},
+		Lunch:         StyleDef{Fill: "#F6EFBD", Pattern: 1, Border: "thin", BorderColor: "000000", Label: "Lunch"},
+		Assigned:      StyleDef{Fill: "#FFFFFF", Pattern: 1, Border: "thin", BorderColor: "000000", Label: "Roll"},
+		NotesTitle:    "Noteringar",
+		Note:          StyleDef{Fill: "#FFF8DC", Pattern: 1, Border: "thin", BorderColor: "000000"},
+		NoteHigh:      StyleDef{Fill: "#F8CBAD", Pattern: 1, Bold: true, Border: "thin", BorderColor: "000000"},
+		AbsencesTitle: "Frånvarande",
+		Absent:        StyleDef{Fill: "#F4D793", Pattern: 1, FontColor: "808080", Strike: true, Border: "thin", BorderColor: "000000"},
+		AbsentPartial: StyleDef{Fill: "#F8CBAD", Pattern: 1, Italic: true, Border: "thin", BorderColor: "000000"},
+		Late:          StyleDef{Fill: "#F8696B", Pattern: 1, Border: "thin", BorderColor: "000000", Label: "Sen"},
+		Early:         StyleDef{Fill: "#FFC000", Pattern: 1, Border: "thin", BorderColor: "000000", Label: "Gick tidigt"},
+		Missing:       StyleDef{Fill: "#7F7F7F", Pattern: 1, FontColor: "FFFFFF", Border: "thin", BorderColor: "000000", Label: "Saknas"},
+		Extra:         StyleDef{Fill: "#9BC2E6", Pattern: 1, Border: "thin", BorderColor: "000000", Label: "Extra"},
+		Changed:       StyleDef{Fill: "#FFFF00", Pattern: 1, Bold: true, Border: "thin", BorderColor: "000000", Label: "Ändrad"},
+	},
+	// Okabe-Ito colours, distinguishable with common colour vision deficiencies
+	"colorblind": {
+		LegendTitle:   "Förklaring",
+		Title:         StyleDef{Fill: "#0072B2", Pattern: 1, FontColor: "FFFFFF", FontSize: 20, Bold: true, Border: "thin", BorderColor: "000000"},
+		Holiday:       StyleDef{Fill: "#D55E00", Pattern: 1, FontColor: "FFFFFF", FontSize: 20, Bold: true, Border: "thin", BorderColor: "000000"},
+		Header:        StyleDef{Fill: "#56B4E9", Pattern: 1, Bold: true, Border: "thin", BorderColor: "000000"},
+		Name:          StyleDef{Fill: "#F0E442", Pattern: 1, Border: "thin", BorderColor: "000000"},
+		Free:          StyleDef{Fill: "#999999", Pattern: 1, Border: "thin", BorderColor: "000000", Label: "Ledig"},
+		Work:          StyleDef{Fill: "#FFFFFF", Pattern: 1, Border: "thin", BorderColor: "000000", Label: "Arbete"},
+		Lunch:         StyleDef{Fill: "#E69F00", Pattern: 1, Border: "thin", BorderColor: "000000", Label: "Lunch"},
+		Assigned:      StyleDef{Fill: "#009E73", Pattern: 1, FontColor: "FFFFFF", Border: "thin", BorderColor: "000000", Label: "Roll"},
+		NotesTitle:    "Noteringar",
+		Note:          StyleDef{Fill: "#FFFFFF", Pattern: 1, Border: "thin", BorderColor: "000000"},
+		NoteHigh:      StyleDef{Fill: "#D55E00", Pattern: 1, FontColor: "FFFFFF", Bold: true, Border: "thin", BorderColor: "000000"},
+		AbsencesTitle: "Frånvarande",
+		Absent:        StyleDef{Fill: "#F0E442", Pattern: 1, FontColor: "555555", Strike: true, Border: "thin", BorderColor: "000000"},
+		AbsentPartial: StyleDef{Fill: "#CC79A7", Pattern: 1, Italic: true, Border: "thin", BorderColor: "000000"},
+		Late:          StyleDef{Fill: "#D55E00", Pattern: 1, FontColor: "FFFFFF", Border: "thin", BorderColor: "000000", Label: "Sen"},
+		Early:         StyleDef{Fill: "#E69F00", Pattern: 1, Border: "thin", BorderColor: "000000", Label: "Gick tidigt"},
+		Missing:       StyleDef{Fill: "#000000", Pattern: 1, FontColor: "FFFFFF", Border: "thin", BorderColor: "000000", Label: "Saknas"},
+		Extra:         StyleDef{Fill: "#56B4E9", Pattern: 1, Border: "thin", BorderColor: "000000", Label: "Extra"},
+		Changed:       StyleDef{Fill: "#CC79A7", Pattern: 1, Bold: true, Border: "thin", BorderColor: "000000", Label: "Ändrad"},
+	},
+	// Black and white, states told apart by fill patterns
+	"print": {
+		LegendTitle:   "Förklaring",
+		Title:         StyleDef{Fill: "#FFFFFF", Pattern: 1, FontColor: "000000", FontSize: 20, Bold: true, Border: "medium", BorderColor: "000000"},
+		Holiday:       StyleDef{Fill: "#FFFFFF", Pattern: 1, FontColor: "000000", FontSize: 20, Bold: true, Italic: true, Border: "thick", BorderColor: "000000"},
+		Header:        StyleDef{Fill: "#000000", Pattern: 18, Bold: true, Border: "medium", BorderColor: "000000"},
+		Name:          StyleDef{Fill: "#FFFFFF", Pattern: 1, Bold: true, Border: "thin", BorderColor: "000000"},
+		Free:          StyleDef{Fill: "#000000", Pattern: 4, Border: "thin", BorderColor: "000000", Label: "Ledig"},
+		Work:          StyleDef{Fill: "#FFFFFF", Pattern: 1, Border: "thin", BorderColor: "000000", Label: "Arbete"},
+		Lunch:         StyleDef{Fill: "#000000", Pattern: 13, Border: "thin", BorderColor: "000000", Label: "Lunch"},
+		Assigned:      StyleDef{Fill: "#FFFFFF", Pattern: 1, Bold: true, Border: "thick", BorderColor: "000000", Label: "Roll"},
+		NotesTitle:    "Noteringar",
+		Note:          StyleDef{Fill: "#FFFFFF", Pattern: 1, Border: "thin", BorderColor: "000000"},
+		NoteHigh:      StyleDef{Fill: "#FFFFFF", Pattern: 1, Bold: true, Border: "thick", BorderColor: "000000"},
+		AbsencesTitle: "Frånvarande",
+		Absent:        StyleDef{Fill: "#FFFFFF", Pattern: 1, Bold: true, Strike: true, Border: "thin", BorderColor: "000000"},
+		AbsentPartial: StyleDef{Fill: "#FFFFFF", Pattern: 1, Bold: true, Italic: true, Border: "dashed", BorderColor: "000000"},
+		Late:          StyleDef{Fill: "#000000", Pattern: 11, Border: "thin", BorderColor: "000000", Label: "Sen"},
+		Early:         StyleDef{Fill: "#000000", Pattern: 12, Border: "thin", BorderColor: "000000", Label: "Gick tidigt"},
+		Missing:       StyleDef{Fill: "#000000", Pattern: 3, Border: "thick", BorderColor: "000000", Label: "Saknas"},
+		Extra:         StyleDef{Fill: "#FFFFFF", Pattern: 1, Bold: true, Border: "dashed", BorderColor: "000000", Label: "Extra"},
+		Changed:       StyleDef{Fill: "#FFFFFF", Pattern: 1, Bold: true, Italic: true, Border: "thick", BorderColor: "000000", Label: "Ändrad"},
+	},
+}
+
+var borderStyles = map[string]int{
+	"none":   0,
+	"thin":   1,
+	"medium": 2,
+	"dashed": 3,
+	"dotted": 4,
+	"thick":  5,
+}
+
+// resolveTheme applies the overrides on top of the selected palette
+func resolveTheme(t ThemeOptions) (ThemeOptions, error) {
+	name := t.Palette
+	if name == "" {
+		name = "default"
+	}
+	base, ok := palettes[name]
+	if !ok {
+		return t, fmt.Errorf("unknown palette %q", t.Palette)
+	}
+	base.Palette = name
+	base.Legend = t.Legend
+	if t.LegendTitle != "" {
+		base.LegendTitle = t.LegendTitle
+	}
+	if t.NotesTitle != "" {
+		base.NotesTitle = t.NotesTitle
+	}
+	if t.AbsencesTitle != "" {
+		base.AbsencesTitle = t.AbsencesTitle
+	}
+	base.Title = base.Title.merge(t.Title)
+	base.Holiday = base.Holiday.merge(t.Holiday)
+	base.Header = base.Header.merge(t.Header)
+	base.Name = base.Name.merge(t.Name)
+	base.Free = base.Free.merge(t.Free)
+	base.Work = base.Work.merge(t.Work)
+	base.Lunch = base.Lunch.merge(t.Lunch)
+	base.Assigned = base.Assigned.merge(t.Assigned)
+	base.Note = base.Note.merge(t.Note)
+	base.NoteHigh = base.NoteHigh.merge(t.NoteHigh)
+	base.Absent = base.Absent.merge(t.Absent)
+	base.AbsentPartial = base.AbsentPartial.merge(t.AbsentPartial)
+	base.Late = base.Late.merge(t.Late)
+	base.Early = base.Early.merge(t.Early)
+	base.Missing = base.Missing.merge(t.Missing)
+	base.Extra = base.Extra.merge(t.Extra)
+	base.Changed = base.Changed.merge(t.Changed)
+	base.Roles = map[string]StyleDef{}
+	for role, def := range t.Roles {
+		// Roles start out as the assigned style
+		if def.Label == "" {
+			def.Label = role
+		}
+		base.Roles[role] = base.Assigned.merge(def)
+	}
+
+	for _, def := range append(base.roleDefs(), base.Title, base.Holiday, base.Header, base.Name, base.Free, base.Work, base.Lunch, base.Assigned, base.Note, base.NoteHigh, base.Absent, base.AbsentPartial,
+		base.Late, base.Early, base.Missing, base.Extra, base.Changed) {
+		if _, ok := borderStyles[def.Border]; !ok && def.Border != "" {
+			return t, fmt.Errorf("unknown border %q", def.Border)
+		}
+	}
+	return base, nil
+}
+
+func (t ThemeOptions) roleDefs() []StyleDef {
+	defs := []StyleDef{}
+	for _, role := range t.roleNames() {
+		defs = append(defs, t.Roles[role])
+	}
+	return defs
+}
+
+func (t ThemeOptions) roleNames() []string {
+	names := make([]string, 0, len(t.Roles))
+	for role := range t.Roles {
+		names = append(names, role)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// merge returns d with every field set in o replaced
+func (d StyleDef) merge(o StyleDef) StyleDef {
+	if o.Fill != "" {
+		d.Fill = o.Fill
+	}
+	if o.Pattern != 0 {
+		d.Pattern = o.Pattern
+	}
+	if o.FontColor != "" {
+		d.FontColor = o.FontColor
+	}
+	if o.FontSize != 0 {
+		d.FontSize = o.FontSize
+	}
+	d.Bold = d.Bold || o.Bold
+	d.Italic = d.Italic || o.Italic
+	d.Strike = d.Strike || o.Strike
+	if o.Border != "" {
+		d.Border = o.Border
+	}
+	if o.BorderColor != "" {
+		d.BorderColor = o.BorderColor
+	}
+	if o.Label != "" {
+		d.Label = o.Label
+	}
+	return d
+}
+
+// excelStyle converts the definition into an excelize style
+func (d StyleDef) excelStyle(horizontal string) *excelize.Style {
+	style := &excelize.Style{
+		Fill: excelize.Fill{
+			Type:    "pattern",
+			Color:   []string{d.Fill},
+			Pattern: d.Pattern,
+		},
+		Font: &excelize.Font{
+			Bold:   d.Bold,
+			Italic: d.Italic,
+			Strike: d.Strike,
+			Size:   d.FontSize,
+			Color:  d.FontColor,
+		},
+	}
+	if d.Fill == "" {
+		style.Fill = excelize.Fill{}
+	}
+	if border := borderStyles[d.Border]; border != 0 {
+		for _, side := range []string{"left", "top", "right", "bottom"} {
+			style.Border = append(style.Border, excelize.Border{Type: side, Color: d.BorderColor, Style: border})
+		}
+	}
+	if horizontal != "" {
+		style.Alignment = &excelize.Alignment{Horizontal: horizontal}
+	}
+	return style
+}
+
+/*
+================================================================================
+Only Styles below this line
+================================================================================
+*/
+func setStyles(f *excelize.File, theme ThemeOptions) (sheetStyles, error) {
+	styles := sheetStyles{roles: map[string]int{}}
+	for _, s := range []struct {
+		name       string
+		def        StyleDef
+		horizontal string
+		id         *int
+	}{
+		{"styleTitle", theme.Title, "center", &styles.title},
+		{"styleHoliday", theme.Holiday, "center", &styles.holiday},
+		{"styleHeader", theme.Header, "center", &styles.header},
+		{"styleName", theme.Name, "", &styles.name},
+		{"styleFree", theme.Free, "center", &styles.free},
+		{"styleWork", theme.Work, "center", &styles.work},
+		{"styleLunch", theme.Lunch, "center", &styles.lunch},
+		{"styleAssigned", theme.Assigned, "center", &styles.assigned},
+		{"styleNote", theme.Note, "left", &styles.note},
+		{"styleNoteHigh", theme.NoteHigh, "left", &styles.noteHigh},
+		{"styleAbsent", theme.Absent, "", &styles.absent},
+		{"styleAbsentPartial", theme.AbsentPartial, "", &styles.absentPartial},
+		{"styleLate", theme.Late, "center", &styles.late},
+		{"styleEarly", theme.Early, "center", &styles.early},
+		{"styleMissing", theme.Missing, "center", &styles.missing},
+		{"styleExtra", theme.Extra, "center", &styles.extra},
+		{"styleChanged", theme.Changed, "", &styles.changed},
+	} {
+		id, err := f.NewStyle(s.def.excelStyle(s.horizontal))
+		if err != nil {
+			return styles, errors.New("Failed to create " + s.name + ": " + err.Error())
+		}
+		*s.id = id
+	}
+	for role, def := range theme.Roles {
+		id, err := f.NewStyle(def.excelStyle("center"))
+		if err != nil {
+			return styles, fmt.Errorf("Failed to create style for role %s: %v", role, err)
+		}
+		styles.roles[role] = id
+	}
+
+	return styles, nil
+}
+
+// writeLegend renders one row explaining the cell colours, starting at col/row
+func writeLegend(f *excelize.File, sheet string, theme ThemeOptions, styles sheetStyles, col int, row int) error {
+	cell, err := excelize.CoordinatesToCellName(col, row)
+	if err != nil {
+		return fmt.Errorf("error calculating legend cell: %v", err)
+	}
+	f.SetCellValue(sheet, cell, theme.LegendTitle)
+	f.SetCellStyle(sheet, cell, cell, styles.header)
+
+	entries := []struct {
+		label string
+		style int
+	}{
+		{theme.Work.Label, styles.work},
+		{theme.Lunch.Label, styles.lunch},
+		{theme.Free.Label, styles.free},
+		{theme.Assigned.Label, styles.assigned},
+	}
+	for _, role := range theme.roleNames() {
+		entries = append(entries, struct {
+			label string
+			style int
+		}{theme.Roles[role].Label, styles.roles[role]})
+	}
+	for i, entry := range entries {
+		cell, err := excelize.CoordinatesToCellName(col+1+i, row)
+		if err != nil {
+			return fmt.Errorf("error calculating legend cell: %v", err)
+		}
+		f.SetCellValue(sheet, cell, entry.label)
+		f.SetCellStyle(sheet, cell, cell, entry.style)
+	}
+	return nil
+}
@@ -0,0 +1,90 @@
+package core
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/xuri/excelize/v2"
+)
+
+func TestResolveTheme(t *testing.T) {
+	theme, err := resolveTheme(ThemeOptions{})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if theme.Palette != "default" || theme.Lunch.Fill != "#F6EFBD" {
+		t.Fatalf("expected default palette, got %+v", theme)
+	}
+
+	theme, err = resolveTheme(ThemeOptions{
+		Palette: "print",
+		Lunch:   StyleDef{Fill: "#123456"},
+		Roles:   map[string]StyleDef{"Kassa": {Fill: "#00FF00"}},
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if theme.Lunch.Fill != "#123456" || theme.Lunch.Pattern != 13 {
+		t.Fatalf("expected override on top of print palette, got %+v", theme.Lunch)
+	}
+	kassa := theme.Roles["Kassa"]
+	if kassa.Fill != "#00FF00" || kassa.Border != "thick" || kassa.Label != "Kassa" {
+		t.Fatalf("expected role based on assigned style, got %+v", kassa)
+	}
+
+	if _, err := resolveTheme(ThemeOptions{Palette: "neon"}); err == nil {
+		t.Fatalf("expected error for unknown palette")
+	}
+	if _, err := resolveTheme(ThemeOptions{Work: StyleDef{Border: "wavy"}}); err == nil {
+		t.Fatalf("expected error for unknown border")
+	}
+}
+
+func TestThemeFromConfigWithLegend(t *testing.T) {
+	config := `
+[theme]
+palette = "colorblind"
+legend = true
+
+[theme.roles.Kassa]
+fill = "#00FF00"
+label = "Kassa"
+`
+	opts, err := LoadProcessOptions(strings.NewReader(config), "toml")
+	if err != nil {
+		t.Fatalf("loading options: %v", err)
+	}
+	settings := excelize.NewFile()
+	settings.SetSheetRow("Sheet1", "A1", &[]interface{}{"Inställningar"})
+	settings.SetSheetRow("Sheet1", "A2", &[]interface{}{"Id", "Telefon", "Roll"})
+	settings.SetSheetRow("Sheet1", "A3", &[]interface{}{"1", "111", "Kassa"})
+	settingsBuf, _ := settings.WriteToBuffer()
+
+	input := buildInputFile(t, [][]string{
+		{"1", "Svensson", "Anna", "Pass", "2025-03-17", "10:00 - 14:00", "Kassa"},
+	})
+	result, err := ProcessFilesWithOptions(input, bytes.NewReader(settingsBuf.Bytes()), nil, opts)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	f, err := excelize.OpenReader(bytes.NewReader(result["Vecka 12.xlsx"]))
+	if err != nil {
+		t.Fatalf("opening result: %v", err)
+	}
+	defer f.Close()
+
+	// Assigned hour cell uses the role fill
+	styleID, _ := f.GetCellStyle("Monday", "D3")
+	style, _ := f.GetStyle(styleID)
+	if len(style.Fill.Color) == 0 || !strings.EqualFold(strings.TrimPrefix(style.Fill.Color[0], "#"), "00FF00") {
+		t.Fatalf("expected role fill on assigned hour, got %+v", style.Fill)
+	}
+	// Legend two rows below the trailing header
+	if got, _ := f.GetCellValue("Monday", "A6"); got != "Förklaring" {
+		t.Fatalf("expected legend title in A6, got %q", got)
+	}
+	if got, _ := f.GetCellValue("Monday", "F6"); got != "Kassa" {
+		t.Fatalf("expected role entry in legend, got %q", got)
+	}
+}
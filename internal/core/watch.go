@@ -0,0 +1,246 @@
+package core
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/fsnotify/fsnotify"
+)
+
+// WatchConfig configures the watch-folder mode
+type WatchConfig struct {
+	InputDir     string        // folder where exports are dropped
+	OutputDir    string        // generated schedules are written here
+	ArchiveDir   string        // processed inputs are moved here, default InputDir/processed
+	FailedDir    string        // failed inputs are moved here, default InputDir/failed
+	SettingsName string        // settings file in InputDir, default settings.xlsx
+	FooterName   string        // footer file in InputDir, default footer.xlsx
+	Debounce     time.Duration // wait for writes to settle, default 2s
+	Options      ProcessOptions
+}
+
+func (c WatchConfig) withDefaults() WatchConfig {
+	if c.ArchiveDir == "" {
+		c.ArchiveDir = filepath.Join(c.InputDir, "processed")
+	}
+	if c.FailedDir == "" {
+		c.FailedDir = filepath.Join(c.InputDir, "failed")
+	}
+	if c.SettingsName == "" {
+		c.SettingsName = "settings.xlsx"
+	}
+	if c.FooterName == "" {
+		c.FooterName = "footer.xlsx"
+	}
+	if c.Debounce <= 0 {
+		c.Debounce = 2 * time.Second
+	}
+	return c
+}
+
+/*
+================================================================================
+Watch a folder and generate schedules when exports land
+================================================================================
+*/
+func RunWatch(ctx context.Context, cfg WatchConfig) error {
+	cfg = cfg.withDefaults()
+	if cfg.InputDir == "" || cfg.OutputDir == "" {
+		return errors.New("input and output folders are required")
+	}
+	for _, dir := range []string{cfg.OutputDir, cfg.ArchiveDir, cfg.FailedDir} {
+		if err := os.MkdirAll(dir, 0755); err != nil {
+			return fmt.Errorf("error creating folder %s: %v", dir, err)
+		}
+	}
+
+	watcher, err := fsnotify.NewWatcher()
+	if err != nil {
+		return errors.New("Error creating watcher: " + err.Error())
+	}
+	defer watcher.Close()
+	if err := watcher.Add(cfg.InputDir); err != nil {
+		return errors.New("Error watching folder: " + err.Error())
+	}
+	log.Printf("Watching %s, writing schedules to %s", cfg.InputDir, cfg.OutputDir)
+
+	// Debounce per file, a timer is restarted on every write
+	var mu sync.Mutex
+	var wg sync.WaitGroup
+	timers := map[string]*time.Timer{}
+	schedule := func(path string) {
+		mu.Lock()
+		defer mu.Unlock()
+		if t, ok := timers[path]; ok && t.Stop() {
+			t.Reset(cfg.Debounce)
+			return
+		}
+		wg.Add(1)
+		var t *time.Timer
+		t = time.AfterFunc(cfg.Debounce, func() {
+			defer wg.Done()
+			mu.Lock()
+			if timers[path] == t {
+				delete(timers, path)
+			}
+			mu.Unlock()
+			processWatchedFile(cfg, path)
+		})
+		timers[path] = t
+	}
+
+	// Exports already waiting in the folder
+	entries, err := os.ReadDir(cfg.InputDir)
+	if err != nil {
+		return errors.New("Error reading folder: " + err.Error())
+	}
+	for _, entry := range entries {
+		if !entry.IsDir() && cfg.isExport(entry.Name()) {
+			schedule(filepath.Join(cfg.InputDir, entry.Name()))
+		}
+	}
+
+	defer func() {
+		// Stop pending timers, let running ones finish
+		mu.Lock()
+		for path, t := range timers {
+			if t.Stop() {
+				wg.Done()
+			}
+			delete(timers, path)
+		}
+		mu.Unlock()
+		wg.Wait()
+	}()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case event, ok := <-watcher.Events:
+			if !ok {
+				return nil
+			}
+			if event.Has(fsnotify.Create) || event.Has(fsnotify.Write) {
+				if cfg.isExport(filepath.Base(event.Name)) {
+					schedule(event.Name)
+				}
+			}
+		case err, ok := <-watcher.Errors:
+			if !ok {
+				return nil
+			}
+			log.Println("Watcher error:", err)
+		}
+	}
+}
+
+// isExport reports whether a file in the input folder should be processed
+func (c WatchConfig) isExport(name string) bool {
+	if !strings.EqualFold(filepath.Ext(name), ".xlsx") {
+		return false
+	}
+	// Skip office lock files and the folder's own settings/footer
+	if strings.HasPrefix(name, "~$") || strings.HasPrefix(name, ".") {
+		return false
+	}
+	if strings.EqualFold(name, c.SettingsName) || strings.EqualFold(name, c.FooterName) {
+		return false
+	}
+	for _, src := range OptionalSources {
+		if strings.EqualFold(strings.TrimSuffix(name, filepath.Ext(name)), src.Key) {
+			return false
+		}
+	}
+	return true
+}
+
+// processWatchedFile renders one export and archives it, or moves it to the failed folder
+func processWatchedFile(cfg WatchConfig, path string) {
+	if _, err := os.Stat(path); err != nil {
+		// Already handled or removed again
+		return
+	}
+	log.Println("Processing", path)
+
+	err := renderWatchedFile(cfg, path)
+	stamp := time.Now().Format("20060102-150405")
+	if err != nil {
+		log.Printf("Failed processing %s: %v", path, err)
+		dst := filepath.Join(cfg.FailedDir, stamp+"_"+filepath.Base(path))
+		if mvErr := os.Rename(path, dst); mvErr != nil {
+			log.Println("Error moving failed file:", mvErr)
+			return
+		}
+		os.WriteFile(dst+".error.txt", []byte(err.Error()+"\n"), 0644)
+		return
+	}
+
+	dst := filepath.Join(cfg.ArchiveDir, stamp+"_"+filepath.Base(path))
+	if err := os.Rename(path, dst); err != nil {
+		log.Println("Error archiving file:", err)
+		return
+	}
+	log.Println("Archived", path, "to", dst)
+}
+
+func renderWatchedFile(cfg WatchConfig, path string) (err error) {
+	// One bad file must not stop the daemon, it goes to the failed folder instead
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("panic: %v", r)
+		}
+	}()
+	input, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer input.Close()
+
+	settings, closeSettings := openOptional(filepath.Join(cfg.InputDir, cfg.SettingsName))
+	defer closeSettings()
+	footer, closeFooter := openOptional(filepath.Join(cfg.InputDir, cfg.FooterName))
+	defer closeFooter()
+	// Optional data sources are picked up by name, e.g. template.xlsx or values.csv
+	opts := cfg.Options
+	for _, src := range OptionalSources {
+		for _, ext := range []string{".xlsx", ".csv"} {
+			r, closeSource := openOptional(filepath.Join(cfg.InputDir, src.Key+ext))
+			defer closeSource()
+			if r != nil {
+				src.Set(&opts, r)
+				break
+			}
+		}
+	}
+
+	files, err := ProcessFilesWithOptions(input, settings, footer, opts)
+	if err != nil {
+		return err
+	}
+	for name, content := range files {
+		out := filepath.Join(cfg.OutputDir, name)
+		if err := os.WriteFile(out, content, 0644); err != nil {
+			return err
+		}
+		log.Println("File written successfully:", out)
+	}
+	return nil
+}
+
+// openOptional returns nil if the file does not exist
+func openOptional(path string) (io.Reader, func()) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, func() {}
+	}
+	return f, func() { f.Close() }
+}
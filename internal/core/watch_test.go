@@ -0,0 +1,127 @@
+package core
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/xuri/excelize/v2"
+)
+
+func TestWatchConfigIsExport(t *testing.T) {
+	cfg := WatchConfig{}.withDefaults()
+	cases := map[string]bool{
+		"export.xlsx":        true,
+		"EXPORT.XLSX":        true,
+		"~$export.xlsx":      false,
+		"settings.xlsx":      false,
+		"footer.xlsx":        false,
+		"template.xlsx":      false,
+		"export.xlsx.part":   false,
+		".export.xlsx":       false,
+		"notes-for-week.csv": false,
+	}
+	for name, want := range cases {
+		if got := cfg.isExport(name); got != want {
+			t.Fatalf("isExport(%q) = %v, want %v", name, got, want)
+		}
+	}
+}
+
+func TestProcessWatchedFile(t *testing.T) {
+	dir := t.TempDir()
+	cfg := WatchConfig{InputDir: dir, OutputDir: filepath.Join(dir, "out"), Options: DefaultProcessOptions()}.withDefaults()
+	for _, d := range []string{cfg.OutputDir, cfg.ArchiveDir, cfg.FailedDir} {
+		os.MkdirAll(d, 0755)
+	}
+
+	input := buildInputFile(t, [][]string{
+		{"1", "Svensson", "Anna", "Pass", "2025-03-17", "09:00 - 17:00", "Kassa"},
+	})
+	good := filepath.Join(dir, "export.xlsx")
+	content := make([]byte, input.Len())
+	input.Read(content)
+	os.WriteFile(good, content, 0644)
+	bad := filepath.Join(dir, "broken.xlsx")
+	os.WriteFile(bad, []byte("not a workbook"), 0644)
+
+	processWatchedFile(cfg, good)
+	processWatchedFile(cfg, bad)
+
+	if _, err := os.Stat(filepath.Join(cfg.OutputDir, "Vecka 12.xlsx")); err != nil {
+		t.Fatalf("expected generated schedule: %v", err)
+	}
+	if _, err := os.Stat(good); !os.IsNotExist(err) {
+		t.Fatalf("expected processed input to be moved away")
+	}
+	archived, _ := os.ReadDir(cfg.ArchiveDir)
+	if len(archived) != 1 || !strings.HasSuffix(archived[0].Name(), "_export.xlsx") {
+		t.Fatalf("unexpected archive content: %v", archived)
+	}
+	failed, _ := os.ReadDir(cfg.FailedDir)
+	if len(failed) != 2 {
+		t.Fatalf("expected failed file and error log, got %v", failed)
+	}
+}
+
+func TestRunWatchPicksUpExistingFiles(t *testing.T) {
+	dir := t.TempDir()
+	os.WriteFile(filepath.Join(dir, "broken.xlsx"), []byte("not a workbook"), 0644)
+	cfg := WatchConfig{InputDir: dir, OutputDir: filepath.Join(dir, "out"), Debounce: 10 * time.Millisecond, Options: DefaultProcessOptions()}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error)
+	go func() { done <- RunWatch(ctx, cfg) }()
+
+	deadline := time.Now().Add(5 * time.Second)
+	for time.Now().Before(deadline) {
+		if entries, _ := os.ReadDir(filepath.Join(dir, "failed")); len(entries) == 2 {
+			break
+		}
+		time.Sleep(20 * time.Millisecond)
+	}
+	cancel()
+	if err := <-done; err != nil {
+		t.Fatalf("RunWatch error: %v", err)
+	}
+	if entries, _ := os.ReadDir(filepath.Join(dir, "failed")); len(entries) != 2 {
+		t.Fatalf("expected existing file to be processed, got %v", entries)
+	}
+}
+
+func TestProcessWatchedFileEarlyShifts(t *testing.T) {
+	dir := t.TempDir()
+	cfg := WatchConfig{InputDir: dir, OutputDir: filepath.Join(dir, "out"), Options: DefaultProcessOptions()}.withDefaults()
+	for _, d := range []string{cfg.OutputDir, cfg.ArchiveDir, cfg.FailedDir} {
+		os.MkdirAll(d, 0755)
+	}
+	// Every shift on Monday ends before hideBefore, the day is still valid
+	input := buildInputFile(t, [][]string{
+		{"1", "Svensson", "Anna", "Pass", "2025-03-17", "06:00 - 09:00", "Kassa"},
+		{"2", "Berg", "Erik", "Pass", "2025-03-18", "10:00 - 14:00", "Kassa"},
+	})
+	early := filepath.Join(dir, "early.xlsx")
+	content := make([]byte, input.Len())
+	input.Read(content)
+	os.WriteFile(early, content, 0644)
+
+	processWatchedFile(cfg, early)
+
+	if logs, _ := filepath.Glob(filepath.Join(cfg.FailedDir, "*")); len(logs) != 0 {
+		t.Fatalf("expected nothing in the failed folder, got %v", logs)
+	}
+	f, err := excelize.OpenFile(filepath.Join(cfg.OutputDir, "Vecka 12.xlsx"))
+	if err != nil {
+		t.Fatalf("opening result: %v", err)
+	}
+	defer f.Close()
+	if got, _ := f.GetCellValue("Monday", "B3"); got != "Anna Svensson" {
+		t.Fatalf("expected the early shift on Monday, got %q", got)
+	}
+	if rows, _ := f.GetRows("Monday"); len(rows[1]) != len(DefaultProcessOptions().headers()) {
+		t.Fatalf("expected no hour columns on Monday, got %v", rows[1])
+	}
+}
@@ -4,13 +4,19 @@ import (
 	"archive/zip"
 	"bytes"
 	"fmt"
+	"io"
 	"net/http"
+	"path/filepath"
+	"sort"
 )
 
 // ProcessFunc is used by the HTTP handler to process uploaded files.
 // Tests can replace this with a stub implementation. By default it
-// points to the real `ProcessFiles` function.
-var ProcessFunc = ProcessFiles
+// points to the real `ProcessFilesWithOptions` function.
+var ProcessFunc = ProcessFilesWithOptions
+
+// ServerOptions are the options used for uploads without a config file
+var ServerOptions = DefaultProcessOptions()
 
 /*
 ================================================================================
@@ -18,6 +24,12 @@ Start web server
 ================================================================================
 */
 func RunHeadless() {
+	RunHeadlessWithOptions(DefaultProcessOptions())
+}
+
+// RunHeadlessWithOptions starts the web server using opts as server defaults
+func RunHeadlessWithOptions(opts ProcessOptions) {
+	ServerOptions = opts
 	http.HandleFunc("/", uploadHandler)
 	fmt.Println("Server started at http://localhost:8999")
 	http.ListenAndServe(":8999", nil)
@@ -59,8 +71,28 @@ func uploadHandler(w http.ResponseWriter, r *http.Request) {
 		defer footerFile.Close()
 	}
 
+	// Optional per-request config file overrides the server options
+	opts := ServerOptions
+	configFile, configHeader, _ := r.FormFile("configFile")
+	if configFile != nil {
+		defer configFile.Close()
+		opts, err = LoadProcessOptions(configFile, filepath.Ext(configHeader.Filename))
+		if err != nil {
+			http.Error(w, "Invalid config file: "+err.Error(), http.StatusBadRequest)
+			return
+		}
+	}
+
+	for _, src := range OptionalSources {
+		sourceFile, _, _ := r.FormFile(src.Key + "File")
+		if sourceFile != nil {
+			defer sourceFile.Close()
+			src.Set(&opts, sourceFile)
+		}
+	}
+
 	// Save or process the files (use injectable ProcessFunc for testability)
-	result, err := ProcessFunc(inputFile, settingsFile, footerFile)
+	result, err := ProcessFunc(inputFile, settingsFile, footerFile, opts)
 	if err != nil {
 		http.Error(w, "Error processing files: "+err.Error(), http.StatusInternalServerError)
 		return
@@ -76,13 +108,7 @@ Zip and return processed files
 */
 func zipAndReturnFiles(w http.ResponseWriter, files map[string][]byte) {
 	var buf bytes.Buffer
-	zipWriter := zip.NewWriter(&buf)
-
-	for name, content := range files {
-		f, _ := zipWriter.Create(name)
-		f.Write(content)
-	}
-	zipWriter.Close()
+	WriteZip(&buf, files)
 
 	w.Header().Set("Content-Disposition", "attachment; filename=schedules.zip")
 	w.Header().Set("Content-Type", "application/zip")
@@ -90,3 +116,24 @@ func zipAndReturnFiles(w http.ResponseWriter, files map[string][]byte) {
 	w.Header().Set("X-Processed", "true")
 	w.Write(buf.Bytes())
 }
+
+// WriteZip writes the processed files as a zip archive, sorted by name
+func WriteZip(w io.Writer, files map[string][]byte) error {
+	zipWriter := zip.NewWriter(w)
+
+	names := make([]string, 0, len(files))
+	for name := range files {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		f, err := zipWriter.Create(name)
+		if err != nil {
+			return err
+		}
+		if _, err := f.Write(files[name]); err != nil {
+			return err
+		}
+	}
+	return zipWriter.Close()
+}
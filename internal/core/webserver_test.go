@@ -100,7 +100,7 @@ func TestUploadHandler_POSTProcessesFiles(t *testing.T) {
 	// Stub ProcessFunc
 	old := ProcessFunc
 	defer func() { ProcessFunc = old }()
-	ProcessFunc = func(input, settings, footer io.Reader) (map[string][]byte, error) {
+	ProcessFunc = func(input, settings, footer io.Reader, opts ProcessOptions) (map[string][]byte, error) {
 		// ensure input is passed
 		b, err := io.ReadAll(input)
 		if err != nil {
@@ -146,3 +146,33 @@ func TestUploadHandler_POSTProcessesFiles(t *testing.T) {
 		t.Fatalf("expected X-Processed header true, got %s", res.Header.Get("X-Processed"))
 	}
 }
+
+func TestUploadHandler_POSTUsesConfigFile(t *testing.T) {
+	old := ProcessFunc
+	defer func() { ProcessFunc = old }()
+	var gotOpts ProcessOptions
+	ProcessFunc = func(input, settings, footer io.Reader, opts ProcessOptions) (map[string][]byte, error) {
+		gotOpts = opts
+		return map[string][]byte{"generated.txt": []byte("ok")}, nil
+	}
+
+	var body bytes.Buffer
+	mw := multipart.NewWriter(&body)
+	fw, _ := mw.CreateFormFile("inputFile", "in.xlsx")
+	fw.Write([]byte("dummyinput"))
+	fw, _ = mw.CreateFormFile("configFile", "options.json")
+	fw.Write([]byte(`{"hideBefore": "07:00"}`))
+	mw.Close()
+
+	req := httptest.NewRequest(http.MethodPost, "/", &body)
+	req.Header.Set("Content-Type", mw.FormDataContentType())
+	rr := httptest.NewRecorder()
+	uploadHandler(rr, req)
+
+	if rr.Code != http.StatusOK {
+		t.Fatalf("expected status 200, got %d", rr.Code)
+	}
+	if gotOpts.HideBefore != "07:00" {
+		t.Fatalf("expected config file options to be used, got %+v", gotOpts)
+	}
+}
@@ -36,7 +36,14 @@ func RunStandalone() {
 		"Input File":    {},
 		"Settings File": {},
 		"Footer File":   {},
+		"Config File":   {},
 	}
+	fileTitles := []string{"Input File", "Settings File", "Footer File"}
+	for _, src := range core.OptionalSources {
+		fileSelections[src.Label] = &FileSelection{}
+		fileTitles = append(fileTitles, src.Label)
+	}
+	fileTitles = append(fileTitles, "Config File")
 
 	var generateBtn *widget.Button
 
@@ -113,7 +120,7 @@ func RunStandalone() {
 	layout.Add(titleLbl)
 
 	// Open file buttons/labels
-	for _, btn := range []string{"Input File", "Settings File", "Footer File"} {
+	for _, btn := range fileTitles {
 		fs := fileSelections[btn]
 		fs.Label = widget.NewLabel("No file selected")
 		fs.Label.TextStyle = fyne.TextStyle{
@@ -156,7 +163,31 @@ func RunStandalone() {
 		}
 		defer f3.Close()
 
-		fileData, err := core.ProcessFiles(f1, f2, f3) // Call the function to generate the schedules
+		opts := core.DefaultProcessOptions()
+		if fileSelections["Config File"].Exists() {
+			opts, err = core.LoadProcessOptionsFile(fileSelections["Config File"].Path)
+			if err != nil {
+				log.Println("Error loading config file:", err)
+				dialog.ShowError(err, mainWindow)
+				return
+			}
+		}
+
+		for _, src := range core.OptionalSources {
+			if !fileSelections[src.Label].Exists() {
+				continue
+			}
+			f, err := os.Open(fileSelections[src.Label].Path)
+			if err != nil {
+				log.Println("Error opening "+strings.ToLower(src.Label)+":", err)
+				dialog.ShowError(err, mainWindow)
+				return
+			}
+			defer f.Close()
+			src.Set(&opts, f)
+		}
+
+		fileData, err := core.ProcessFilesWithOptions(f1, f2, f3, opts) // Call the function to generate the schedules
 		if err != nil {
 			log.Println("Error processing files:", err)
 			dialog.ShowError(err, mainWindow)